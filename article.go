@@ -0,0 +1,287 @@
+package extractor
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/474420502/extractor/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// Article the main content and metadata of news or blog page
+type Article struct {
+	Title   string
+	Byline  string
+	Date    string          // the publish date as written in the page. eg: 2021-03-01T08:00:00Z
+	Image   string          // the url of lead image
+	Content *htmlquery.Node // the cleaned copy of the content node, the document is not changed
+	Text    string          // the text of Content, whitespace normalized
+}
+
+var (
+	regexpPositiveClass = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|text|blog|story`)
+	regexpNegativeClass = regexp.MustCompile(`(?i)comment|meta|footer|footnote|sidebar|widget|nav|menu|share|social|related|promo|sponsor|banner|popup|(^|[-_ ])ads?([-_ ]|$)`)
+	regexpBylineClass   = regexp.MustCompile(`(?i)byline|author`)
+	regexpDateClass     = regexp.MustCompile(`(?i)date|time|publish`)
+	regexpTitleSuffix   = regexp.MustCompile(`\s+[-|–—_]\s+[^-|–—_]+$`)
+)
+
+// boilerplateTags 内容中删除的标签
+var boilerplateTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "iframe": true, "form": true, "nav": true, "aside": true,
+	"footer": true, "button": true, "input": true, "select": true, "textarea": true, "object": true, "embed": true,
+}
+
+// Article extracts the main content by scoring the nodes with text density, link density and tag semantics.
+// returns the Article with nil Content if no content is found
+func (etor *HmtlExtractor) Article() *Article {
+	article := &Article{}
+
+	if top := topCandidate(etor.doc); top != nil {
+		article.Content = cleanContent(top.Clone())
+		article.Text = htmlquery.NormalizeSpace(article.Content.Text())
+	}
+
+	article.Title = etor.firstValue(
+		"//meta[@property='og:title']/@content",
+		"//meta[@name='twitter:title']/@content",
+	)
+	if article.Title == "" {
+		// 去掉网站名的后缀. eg: Title - Site
+		title := etor.firstValue("//title")
+		if t := regexpTitleSuffix.ReplaceAllString(title, ""); utf8.RuneCountInString(t) >= 10 {
+			title = t
+		}
+		article.Title = title
+	}
+	if article.Title == "" {
+		article.Title = etor.firstValue("//h1")
+	}
+
+	article.Byline = etor.firstValue(
+		"//meta[@name='author']/@content",
+		"//meta[@property='article:author']/@content",
+		"//*[@rel='author']",
+		"//*[@itemprop='author']",
+	)
+	if article.Byline == "" {
+		article.Byline = etor.firstClassValue(regexpBylineClass)
+	}
+
+	article.Date = etor.firstValue(
+		"//meta[@property='article:published_time']/@content",
+		"//meta[@itemprop='datePublished']/@content",
+		"//*[@itemprop='datePublished']/@datetime",
+		"//*[@itemprop='datePublished']",
+		"//meta[@name='date']/@content",
+		"//time[@datetime]/@datetime",
+		"//time",
+	)
+	if article.Date == "" {
+		article.Date = etor.firstClassValue(regexpDateClass)
+	}
+
+	article.Image = etor.firstValue(
+		"//meta[@property='og:image']/@content",
+		"//meta[@name='twitter:image']/@content",
+	)
+	if article.Image == "" && article.Content != nil {
+		if img := article.Content.FindOne("//img[@src]"); img != nil {
+			article.Image, _ = img.AttributeValue("src")
+		}
+	}
+	return article
+}
+
+// firstValue 第一个有值的表达式的值
+func (etor *HmtlExtractor) firstValue(exps ...string) string {
+	for _, exp := range exps {
+		if n, err := etor.funcs.Query(etor.doc, exp); err == nil && n != nil {
+			if v := htmlquery.NormalizeSpace(n.Text()); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// firstClassValue 第一个class或者id匹配的短文本元素
+func (etor *HmtlExtractor) firstClassValue(re *regexp.Regexp) string {
+	var value string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if value != "" {
+			return
+		}
+		if n.Type == html.ElementNode {
+			node := (*htmlquery.Node)(n)
+			if re.MatchString(attrValue(node, "class") + " " + attrValue(node, "id")) {
+				if v := htmlquery.NormalizeSpace(node.Text()); v != "" && utf8.RuneCountInString(v) < 100 {
+					value = v
+					return
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk((*html.Node)(etor.doc))
+	return value
+}
+
+// topCandidate 段落的分数加到父元素和祖父元素, 乘以(1 - 链接密度)后最高的元素
+func topCandidate(doc *htmlquery.Node) *htmlquery.Node {
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	addScore := func(n *html.Node, s float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += s
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if boilerplateTags[n.Data] || isUnlikely(n) {
+				return
+			}
+			switch n.Data {
+			case "p", "pre", "td", "blockquote":
+				text := htmlquery.NormalizeSpace((*htmlquery.Node)(n).Text())
+				if size := utf8.RuneCountInString(text); size >= 25 {
+					s := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")) + minFloat(float64(size)/100, 3)
+					addScore(n.Parent, s)
+					if n.Parent != nil {
+						addScore(n.Parent.Parent, s/2)
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk((*html.Node)(doc))
+
+	var top *html.Node
+	var topScore float64
+	for _, n := range candidates {
+		s := scores[n] * (1 - linkDensity(n))
+		if top == nil || s > topScore {
+			top, topScore = n, s
+		}
+	}
+	return (*htmlquery.Node)(top)
+}
+
+func initialScore(n *html.Node) float64 {
+	var s float64
+	switch n.Data {
+	case "article":
+		s = 10
+	case "div":
+		s = 5
+	case "section", "pre", "td", "blockquote":
+		s = 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		s = -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		s = -5
+	}
+	return s + classWeight(n)
+}
+
+// classWeight class和id的语义
+func classWeight(n *html.Node) float64 {
+	var w float64
+	for _, key := range []string{"class", "id"} {
+		v := attrValue((*htmlquery.Node)(n), key)
+		if v == "" {
+			continue
+		}
+		if regexpNegativeClass.MatchString(v) {
+			w -= 25
+		}
+		if regexpPositiveClass.MatchString(v) {
+			w += 25
+		}
+	}
+	return w
+}
+
+// isUnlikely 明显不是正文的元素
+func isUnlikely(n *html.Node) bool {
+	if n.Data == "body" || n.Data == "article" || n.Data == "html" {
+		return false
+	}
+	return classWeight(n) < 0
+}
+
+// linkDensity 链接文本占所有文本的比例
+func linkDensity(n *html.Node) float64 {
+	total := utf8.RuneCountInString(htmlquery.NormalizeSpace((*htmlquery.Node)(n).Text()))
+	if total == 0 {
+		return 0
+	}
+	var links int
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			links += utf8.RuneCountInString(htmlquery.NormalizeSpace((*htmlquery.Node)(n).Text()))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return float64(links) / float64(total)
+}
+
+// cleanContent 删除内容中的样板: 脚本表单导航, 不像正文的class, 链接密度高的块
+func cleanContent(content *htmlquery.Node) *htmlquery.Node {
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			switch c.Type {
+			case html.CommentNode:
+				n.RemoveChild(c)
+			case html.ElementNode:
+				if boilerplateTags[c.Data] || isUnlikely(c) || isLinkBlock(c) {
+					n.RemoveChild(c)
+				} else {
+					walk(c)
+				}
+			}
+			c = next
+		}
+	}
+	walk((*html.Node)(content))
+	return content
+}
+
+// isLinkBlock 链接为主的短块. eg: 相关文章列表
+func isLinkBlock(n *html.Node) bool {
+	switch n.Data {
+	case "div", "ul", "ol", "table", "section", "p":
+	default:
+		return false
+	}
+	text := htmlquery.NormalizeSpace((*htmlquery.Node)(n).Text())
+	return text != "" && utf8.RuneCountInString(text) < 200 && linkDensity(n) > 0.5
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,92 @@
+package extractor
+
+import (
+	"strings"
+	"testing"
+)
+
+const articleHtml = `<html><head>
+	<title>Go 1.20 is released - The Go Blog</title>
+	<meta name="author" content="Jane Doe">
+	<meta property="article:published_time" content="2023-02-01T10:00:00Z">
+</head><body>
+	<div class="header"><nav><a href="/">Home</a> <a href="/blog">Blog</a></nav></div>
+	<div class="sidebar"><p>Subscribe to our newsletter, it is free, fast, and full of news about everything.</p></div>
+	<div id="main">
+		<div class="post-content">
+			<h1>Go 1.20 is released</h1>
+			<img src="/images/gopher.png">
+			<p>Today the Go team is thrilled to release Go 1.20, which you can get by visiting the download page.</p>
+			<p>Go 1.20 benefits from an extended development phase, made possible by earlier broad testing and improved overall stability of the code base.</p>
+			<script>track()</script>
+			<div class="share"><a href="/tw">Twitter</a> <a href="/fb">Facebook</a></div>
+			<p>We're particularly excited to launch a preview of profile-guided optimization, which enables the compiler to perform optimizations.</p>
+			<ul class="related"><li><a href="/go119">Go 1.19 is released</a></li></ul>
+		</div>
+	</div>
+	<div class="footer"><p>Copyright 2023, all rights reserved, the Go Authors, some more words here.</p></div>
+</body></html>`
+
+func TestArticle(t *testing.T) {
+	e := ExtractHtmlString(articleHtml)
+	a := e.Article()
+	if a.Content == nil {
+		t.Fatal("no content")
+	}
+	if class, _ := a.Content.AttributeValue("class"); class != "post-content" {
+		t.Error(a.Content.OutputHTML(true))
+	}
+	if !strings.Contains(a.Text, "Today the Go team") || !strings.Contains(a.Text, "profile-guided") {
+		t.Error(a.Text)
+	}
+	for _, s := range []string{"track()", "Twitter", "Go 1.19", "Subscribe", "Copyright"} {
+		if strings.Contains(a.Text, s) {
+			t.Error(s, "is not stripped:", a.Text)
+		}
+	}
+	if a.Title != "Go 1.20 is released" || a.Byline != "Jane Doe" || a.Date != "2023-02-01T10:00:00Z" || a.Image != "/images/gopher.png" {
+		t.Errorf("%#v", a)
+	}
+
+	// 内容是副本, 可以继续使用xpath
+	if p := a.Content.Find("//p"); len(p) != 3 {
+		t.Error(len(p))
+	}
+	if xp, _ := e.XPath("//script"); xp.Len() != 1 {
+		t.Error("the document is changed")
+	}
+}
+
+func TestArticleMetadataFallback(t *testing.T) {
+	e := ExtractHtmlString(`<html><body><article>
+		<h1>Only a heading</h1>
+		<span class="byline">By John</span> <time datetime="2020-01-02">Jan 2</time>
+		<p>Some long enough paragraph text for the article body, with commas, and more words.</p>
+	</article></body></html>`)
+	a := e.Article()
+	if a.Title != "Only a heading" || a.Byline != "By John" || a.Date != "2020-01-02" || a.Image != "" {
+		t.Errorf("%#v", a)
+	}
+	if a.Content == nil || a.Content.Data != "article" {
+		t.Error(a.Content)
+	}
+
+	if a := ExtractHtmlString(`<html><body></body></html>`).Article(); a.Content != nil || a.Title != "" {
+		t.Errorf("%#v", a)
+	}
+}
+
+func TestArticleRuneLength(t *testing.T) {
+	// 中文按字符计算长度, 20个字的段落不是正文
+	e := ExtractHtmlString(`<html><body>
+		<div class="x"><p>本站使用缓存技术提高访问速度请放心浏览</p><p>本站使用缓存技术提高访问速度请放心浏览</p><p>本站使用缓存技术提高访问速度请放心浏览</p></div>
+		<div class="y"><p>这是一篇很长的文章正文第一段介绍了这次发布的主要内容和新的功能</p><p>第二段详细说明了新的编译器优化以及对现有代码的影响和迁移方法</p></div>
+	</body></html>`)
+	a := e.Article()
+	if a.Content == nil {
+		t.Fatal("no content")
+	}
+	if class, _ := a.Content.AttributeValue("class"); class != "y" {
+		t.Error(a.Content.OutputHTML(true))
+	}
+}
@@ -0,0 +1,219 @@
+package extractor
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/474420502/extractor/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// MatchMode the mode of FindByText
+type MatchMode int
+
+const (
+	// MatchExact the normalized text equals the text
+	MatchExact MatchMode = iota
+	// MatchContains the normalized text contains the text
+	MatchContains
+	// MatchRegexp the normalized text matches the regexp
+	MatchRegexp
+	// MatchSimilar the similarity of the normalized text and the text is not less than Threshold
+	MatchSimilar
+)
+
+// FindOptions the options of FindByText. nil is MatchExact
+type FindOptions struct {
+	Mode       MatchMode
+	IgnoreCase bool
+	Threshold  float64                   // the min similarity of MatchSimilar, 0 is 0.8
+	Similarity func(a, b string) float64 // 0 ~ 1, nil is JaroWinkler
+}
+
+// LabelOptions the options of label tag. eg: label:"Price|価格"
+var LabelOptions = &FindOptions{Mode: MatchSimilar, IgnoreCase: true, Threshold: 0.85}
+
+// FindByText the deepest elements whose whitespace normalized text matches text. the child element matches
+// better(or the same) is used instead of its parent. the results are in document order
+func (etor *HmtlExtractor) FindByText(text string, opts *FindOptions) (*XPath, error) {
+	matches, err := findByText(etor.doc, text, opts)
+	if err != nil {
+		return nil, err
+	}
+	var results []*htmlquery.Node
+	for _, m := range matches {
+		results = append(results, m.node)
+	}
+	xp := newXPath(results...)
+	xp.funcs = etor.funcs
+	return xp, nil
+}
+
+type textMatch struct {
+	node  *htmlquery.Node
+	score float64
+	order int // 文档中的先序位置
+}
+
+func findByText(root *htmlquery.Node, text string, opts *FindOptions) ([]textMatch, error) {
+	if opts == nil {
+		opts = &FindOptions{}
+	}
+	normalize := func(s string) string {
+		s = htmlquery.NormalizeSpace(s)
+		if opts.IgnoreCase {
+			s = strings.ToLower(s)
+		}
+		return s
+	}
+	text = normalize(text)
+
+	var match func(s string) float64
+	switch opts.Mode {
+	case MatchContains:
+		match = func(s string) float64 {
+			if strings.Contains(s, text) {
+				return float64(len(text)+1) / float64(len(s)+1)
+			}
+			return 0
+		}
+	case MatchRegexp:
+		pattern := text
+		if opts.IgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		match = func(s string) float64 {
+			if re.MatchString(s) {
+				return 1
+			}
+			return 0
+		}
+	case MatchSimilar:
+		threshold, similarity := opts.Threshold, opts.Similarity
+		if threshold <= 0 {
+			threshold = 0.8
+		}
+		if similarity == nil {
+			similarity = JaroWinkler
+		}
+		limit := 4*utf8.RuneCountInString(text) + 20
+		match = func(s string) float64 {
+			if utf8.RuneCountInString(s) > limit { // 太长的文本不是要找的
+				return 0
+			}
+			if sim := similarity(s, text); sim >= threshold {
+				return sim
+			}
+			return 0
+		}
+	default:
+		match = func(s string) float64 {
+			if s == text {
+				return 1
+			}
+			return 0
+		}
+	}
+
+	var matches []textMatch
+	order := 0
+	// 返回子树中最好的分数
+	var walk func(n *html.Node) float64
+	walk = func(n *html.Node) float64 {
+		pos := order
+		order++
+		var best float64
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if s := walk(c); s > best {
+				best = s
+			}
+		}
+		if n.Type != html.ElementNode || invisibleTags[n.Data] {
+			return best
+		}
+		node := (*htmlquery.Node)(n)
+		if s := match(normalize(node.Text())); s > best {
+			matches = append(matches, textMatch{node, s, pos})
+			best = s
+		}
+		return best
+	}
+	walk((*html.Node)(root))
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].order < matches[j].order
+	})
+	return matches, nil
+}
+
+// findLabelValues the value nodes next to the labels matched by label tag, the better matched label first.
+// label is split by | . eg: Price|価格
+func findLabelValues(root *htmlquery.Node, label string) []*htmlquery.Node {
+	var matches []textMatch
+	for _, text := range strings.Split(label, "|") {
+		if text = strings.TrimSpace(text); text != "" {
+			m, _ := findByText(root, text, LabelOptions)
+			matches = append(matches, m...)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	var values []*htmlquery.Node
+	seen := make(map[*htmlquery.Node]bool)
+	for _, m := range matches {
+		if v := labelValue(m.node); v != nil && !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// labelValue the value node of the label. <label for> dt/dd th/td, the next sibling with text, or the next sibling of the parents
+func labelValue(label *htmlquery.Node) *htmlquery.Node {
+	switch label.Data {
+	case "label":
+		if id := attrValue(label, "for"); id != "" {
+			if n, err := documentRoot(label).Query("//*[@id=" + htmlquery.XPathLiteral(id) + "]"); err == nil && n != nil {
+				return n
+			}
+		}
+	case "dt":
+		return nextSiblingElement(label, "dd")
+	case "th", "td":
+		return nextSiblingElement(label, "td")
+	}
+
+	for n, depth := label, 0; n != nil && n.Type == html.ElementNode && depth < 3; n, depth = n.GetParent(), depth+1 {
+		for s := n.Next(); s != nil; s = s.Next() {
+			switch s.Type {
+			case html.ElementNode:
+				if htmlquery.NormalizeSpace(s.Text()) != "" {
+					return s
+				}
+			case html.TextNode:
+				if strings.Trim(s.Data, " \t\r\n:：") != "" {
+					return s
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func nextSiblingElement(n *htmlquery.Node, tag string) *htmlquery.Node {
+	for s := n.Next(); s != nil; s = s.Next() {
+		if s.Type == html.ElementNode && s.Data == tag {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package extractor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFindByText(t *testing.T) {
+	e := ExtractHtmlString(`<html><body>
+		<div><span>Price:</span> <b>$10</b></div>
+		<p>Total price (tax incl.)</p>
+		<ul><li>Apple</li><li>apple pie</li></ul>
+		<script>var price = 1</script>
+	</body></html>`)
+
+	find := func(text string, opts *FindOptions) string {
+		xp, err := e.FindByText(text, opts)
+		if err != nil {
+			return err.Error()
+		}
+		return fmt.Sprint(xp.GetTexts())
+	}
+
+	if got := find("Apple", nil); got != "[Apple]" {
+		t.Error(got)
+	}
+	if got := find("apple", &FindOptions{IgnoreCase: true}); got != "[Apple]" {
+		t.Error(got)
+	}
+	if got := find("apple", &FindOptions{Mode: MatchContains, IgnoreCase: true}); got != "[Apple apple pie]" {
+		t.Error(got)
+	}
+	if got := find(`^\$\d+$`, &FindOptions{Mode: MatchRegexp}); got != "[$10]" {
+		t.Error(got)
+	}
+	if got := find("(", &FindOptions{Mode: MatchRegexp}); !strings.Contains(got, "missing closing )") {
+		t.Error(got)
+	}
+	if got := find("price", &FindOptions{Mode: MatchSimilar, IgnoreCase: true}); got != "[Price:]" {
+		t.Error(got)
+	}
+	if got := find("price", &FindOptions{Mode: MatchSimilar, IgnoreCase: true, Threshold: 0.5, Similarity: func(a, b string) float64 {
+		return TokenSetRatio(a, b) / 100
+	}}); got != "[Price: Total price (tax incl.)]" {
+		t.Error(got)
+	}
+}
+
+type labelProduct struct {
+	Price  string   `label:"Price|価格"`
+	Stock  string   `label:"Stock"`
+	Color  string   `label:"Color"`
+	Size   string   `label:"Size"`
+	Email  string   `label:"E-mail" mth:"AttrValue,value"`
+	Prices []string `label:"Price|価格"`
+}
+
+func TestLabelTag(t *testing.T) {
+	var p labelProduct
+	ExtractHtmlString(`<html><body>
+		<div><span>Price (tax incl.):</span> <b>$10</b></div>
+		<dl><dt>Stock</dt><dd>5</dd></dl>
+		<table><tr><th>Color:</th><td>red</td></tr></table>
+		<p><b>Size:</b> XL</p>
+		<label for="mail">E-mail</label> <div><input id="mail" value="a@b.c"></div>
+	</body></html>`).GetObjectByTag(&p)
+	if p.Price != "$10" || p.Stock != "5" || p.Color != "red" || strings.TrimSpace(p.Size) != "XL" || p.Email != "a@b.c" {
+		t.Errorf("%#v", p)
+	}
+
+	var jp labelProduct
+	ExtractHtmlString(`<div><span>価格</span><span>1,200</span></div>`).GetObjectByTag(&jp)
+	if jp.Price != "1,200" || jp.Stock != "" || fmt.Sprint(jp.Prices) != "[1,200]" {
+		t.Errorf("%#v", jp)
+	}
+}
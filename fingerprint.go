@@ -0,0 +1,206 @@
+package extractor
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// Fingerprint the SimHash fingerprints of the page. the near duplicate pages have small hamming distance
+type Fingerprint struct {
+	Text      uint64 `json:"text"`      // SimHash of the 3-word shingles of visible text
+	Structure uint64 `json:"structure"` // SimHash of the tag paths(up to 3 levels) of the elements
+}
+
+// invisibleTags 不计算可见文本的标签
+var invisibleTags = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true, "iframe": true, "svg": true,
+}
+
+// Fingerprint computes the SimHash over the visible text and the tag tree of the page
+func (etor *HmtlExtractor) Fingerprint() Fingerprint {
+	var words, paths []string
+	var walk func(n *html.Node, path []string)
+	walk = func(n *html.Node, path []string) {
+		switch n.Type {
+		case html.TextNode:
+			words = append(words, textTokens(n.Data)...)
+			return
+		case html.ElementNode:
+			if invisibleTags[n.Data] {
+				return
+			}
+			path = append(path, n.Data)
+			if len(path) > 3 {
+				path = path[len(path)-3:]
+			}
+			paths = append(paths, strings.Join(path, "/"))
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, path)
+		}
+	}
+	walk((*html.Node)(etor.doc), nil)
+
+	var shingles []string
+	if len(words) < 3 {
+		shingles = words
+	}
+	for i := 0; i+3 <= len(words); i++ {
+		shingles = append(shingles, strings.Join(words[i:i+3], " "))
+	}
+	return Fingerprint{Text: simhash(shingles), Structure: simhash(paths)}
+}
+
+// Distance the hamming distances of the text and structure fingerprints
+func (fp Fingerprint) Distance(other Fingerprint) (text, structure int) {
+	return bits.OnesCount64(fp.Text ^ other.Text), bits.OnesCount64(fp.Structure ^ other.Structure)
+}
+
+// Similarity 0 ~ 1. the text weights 0.7, the structure weights 0.3
+func (fp Fingerprint) Similarity(other Fingerprint) float64 {
+	text, structure := fp.Distance(other)
+	return 0.7*(1-float64(text)/64) + 0.3*(1-float64(structure)/64)
+}
+
+// textTokens 小写的单词, 中日韩文字每个字是一个词
+func textTokens(s string) []string {
+	var tokens []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+func simhash(features []string) uint64 {
+	var v [64]int
+	h := fnv.New64a()
+	for _, f := range features {
+		h.Reset()
+		h.Write([]byte(f))
+		sum := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				v[i]++
+			} else {
+				v[i]--
+			}
+		}
+	}
+
+	var fp uint64
+	for i := 0; i < 64; i++ {
+		if v[i] > 0 {
+			fp |= 1 << uint(i)
+		}
+	}
+	return fp
+}
+
+// NearDuplicate the near duplicate of the query in FingerprintIndex
+type NearDuplicate struct {
+	ID                string
+	TextDistance      int
+	StructureDistance int
+}
+
+// FingerprintIndex the in-memory LSH index of the text fingerprints. the fingerprint is split to MaxDistance+1 bands,
+// the fingerprints within MaxDistance share at least one band. it is safe for concurrent use
+type FingerprintIndex struct {
+	mu          sync.RWMutex
+	maxDistance int
+	bands       []map[uint64][]int // band value -> the indexes of fps
+	ids         []string
+	fps         []Fingerprint
+}
+
+// NewFingerprintIndex new index finds the fingerprints whose text distance is not greater than maxDistance. eg: 3
+func NewFingerprintIndex(maxDistance int) *FingerprintIndex {
+	if maxDistance < 0 {
+		maxDistance = 0
+	}
+	if maxDistance > 63 {
+		maxDistance = 63
+	}
+	idx := &FingerprintIndex{maxDistance: maxDistance}
+	for i := 0; i <= maxDistance; i++ {
+		idx.bands = append(idx.bands, make(map[uint64][]int))
+	}
+	return idx
+}
+
+// band the i-th band of the fingerprint
+func (idx *FingerprintIndex) band(fp uint64, i int) uint64 {
+	n := len(idx.bands)
+	start, end := 64*i/n, 64*(i+1)/n
+	return fp << uint(64-end) >> uint(64-end+start)
+}
+
+// Add adds the fingerprint of the document id
+func (idx *FingerprintIndex) Add(id string, fp Fingerprint) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	i := len(idx.fps)
+	idx.ids = append(idx.ids, id)
+	idx.fps = append(idx.fps, fp)
+	for b, band := range idx.bands {
+		key := idx.band(fp.Text, b)
+		band[key] = append(band[key], i)
+	}
+}
+
+// Len the count of the fingerprints
+func (idx *FingerprintIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.fps)
+}
+
+// Near the documents whose text distance to fp is not greater than maxDistance, sorted by distance
+func (idx *FingerprintIndex) Near(fp Fingerprint) []*NearDuplicate {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var result []*NearDuplicate
+	seen := make(map[int]bool)
+	for b, band := range idx.bands {
+		for _, i := range band[idx.band(fp.Text, b)] {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			if text, structure := fp.Distance(idx.fps[i]); text <= idx.maxDistance {
+				result = append(result, &NearDuplicate{ID: idx.ids[i], TextDistance: text, StructureDistance: structure})
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].TextDistance != result[j].TextDistance {
+			return result[i].TextDistance < result[j].TextDistance
+		}
+		return result[i].StructureDistance < result[j].StructureDistance
+	})
+	return result
+}
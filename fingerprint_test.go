@@ -0,0 +1,85 @@
+package extractor
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	a := ExtractHtmlString(`<html><head><title>News</title><script>var sid = "abc";</script></head><body>
+		<div class="nav"><a href="/?sid=abc">Home</a></div>
+		<div class="article">
+			<h1>The city council approved the new budget</h1>
+			<p>The city council on Monday approved a budget of 2.5 billion dollars for the next fiscal year,
+			after a long debate about the funding of public transport, schools and parks.</p>
+			<p>The mayor said the budget keeps taxes flat while adding new bus lines and hiring more teachers.
+			Opponents argued that the plan relies on optimistic revenue forecasts.</p>
+			<p>Read more stories.</p>
+		</div>
+	</body></html>`).Fingerprint()
+	// 只有script和链接里的session不同
+	b := ExtractHtmlString(`<html><head><title>News</title><script>var sid = "xyz";</script></head><body>
+		<div class="nav"><a href="/?sid=xyz">Home</a></div>
+		<div class="article">
+			<h1>The city council approved the new budget</h1>
+			<p>The city council on Monday approved a budget of 2.5 billion dollars for the next fiscal year,
+			after a long debate about the funding of public transport, schools and parks.</p>
+			<p>The mayor said the budget keeps taxes flat while adding new bus lines and hiring more teachers.
+			Opponents argued that the plan relies on optimistic revenue forecasts.</p>
+			<p>Read more stories.</p>
+		</div>
+	</body></html>`).Fingerprint()
+	if a != b {
+		t.Error("the invisible text should be ignored", a, b)
+	}
+
+	c := ExtractHtmlString(`<html><head><title>News</title><script>var sid = "abc";</script></head><body>
+		<div class="nav"><a href="/?sid=abc">Home</a></div>
+		<div class="article">
+			<h1>The city council approved the new budget</h1>
+			<p>The city council on Monday approved a budget of 2.5 billion dollars for the next fiscal year,
+			after a long debate about the funding of public transport, schools and parks.</p>
+			<p>The mayor said the budget keeps taxes flat while adding new bus lines and hiring more teachers.
+			Opponents argued that the plan relies on optimistic revenue forecasts.</p>
+			<p>Read more stories today.</p>
+		</div>
+	</body></html>`).Fingerprint()
+	if text, structure := a.Distance(c); text == 0 || text > 10 || structure != 0 {
+		t.Error(text, structure)
+	}
+
+	other := ExtractHtmlString(`<html><body><table><tr><td>Weather: sunny with light wind in the afternoon and rain at night, temperature 21 degrees</td></tr></table></body></html>`).Fingerprint()
+	if a.Similarity(c) <= a.Similarity(other) || a.Similarity(a) != 1 {
+		t.Error(a.Similarity(c), a.Similarity(other))
+	}
+
+	if fmt.Sprint(textTokens("Hello, 世界 go1.20")) != "[hello 世 界 go1 20]" {
+		t.Error(textTokens("Hello, 世界 go1.20"))
+	}
+}
+
+func TestFingerprintIndex(t *testing.T) {
+	idx := NewFingerprintIndex(3)
+	idx.Add("a", Fingerprint{Text: 0xFFFF0000FFFF0000})
+	idx.Add("b", Fingerprint{Text: 0xFFFF0000FFFF0007}) // 3 bits
+	idx.Add("c", Fingerprint{Text: 0xFFFF0000FFFF000F}) // 4 bits
+	idx.Add("d", Fingerprint{Text: 0x0000FFFF0000FFFF})
+	if idx.Len() != 4 {
+		t.Error(idx.Len())
+	}
+
+	var ids []string
+	for _, nd := range idx.Near(Fingerprint{Text: 0xFFFF0000FFFF0000}) {
+		ids = append(ids, fmt.Sprint(nd.ID, nd.TextDistance))
+	}
+	if fmt.Sprint(ids) != "[a0 b3]" {
+		t.Error(ids)
+	}
+
+	// 每个band都不同的也能找到
+	idx = NewFingerprintIndex(3)
+	idx.Add("e", Fingerprint{Text: 0x0001000100010000})
+	if near := idx.Near(Fingerprint{}); len(near) != 1 || near[0].TextDistance != 3 {
+		t.Error(near)
+	}
+}
@@ -0,0 +1,298 @@
+package extractor
+
+import (
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/474420502/extractor/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// HealThreshold the min score(0 ~ 1) of the node which replaces the missing results of the field
+var HealThreshold = 0.4
+
+// Healer remembers the last good values and nodes of the fields. share it between the pages of the same site.
+// it is safe for concurrent use
+type Healer struct {
+	mu     sync.Mutex
+	fields map[string]*fieldMemory // struct type.field -> memory
+}
+
+// HealedField the field which exp returns nothing and is healed by the remembered values and nodes
+type HealedField struct {
+	Field  string   // struct field name
+	Exp    string   // the exp of the tag which returns nothing. eg: //b[@class='price'] label:"Price" role:"button" name:"Buy"
+	XPath  string   // the proposed replacement xpath
+	Values []string // the healed values
+	Score  float64  // 0 ~ 1 the similarity to the remembered nodes
+}
+
+// NewHealer new healer without memory
+func NewHealer() *Healer {
+	return &Healer{fields: make(map[string]*fieldMemory)}
+}
+
+// maxRememberNodes 列表字段最多记住的节点数
+const maxRememberNodes = 20
+
+type fieldMemory struct {
+	attr  string        // the key if the results are attributes
+	nodes []nodeFeature // the features of the result nodes
+}
+
+// nodeFeature the content and structural neighbourhood of the element
+type nodeFeature struct {
+	paths         []string // 记住的元素的表达式, 修复的时候先试. RobustXPath XPath
+	value         string
+	tag           string
+	label         string // the text of the previous sibling. eg: Price:
+	classes       []string
+	attrKeys      []string
+	parentTag     string
+	parentClasses []string
+}
+
+// GetObjectByTagWithHealer like GetObjectByTag, and heals the fields which exp returns nothing by the memory of healer.
+// the good results are remembered to healer. the healed fields are returned, their values are set to obj.
+// healing works on the node results of the exp(label role), scalar expressions are not healed.
+func (etor *HmtlExtractor) GetObjectByTagWithHealer(obj interface{}, healer *Healer) []*HealedField {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr {
+		log.Panic("obj must ptr")
+	}
+	otype := v.Type().Elem()
+
+	var healed []*HealedField
+	for _, ft := range getFieldTags(otype) {
+		name := otype.Field(ft.Index).Name
+		key := otype.String() + "." + name
+
+		value, err := evaluateTag(etor.funcs, etor.doc, ft)
+		nodes, ok := value.([]*htmlquery.Node)
+		switch {
+		case len(nodes) > 0:
+			healer.remember(key, ft, nodes)
+		case err == nil && !ok: // 标量表达式
+		default:
+			if hf := healer.heal(etor, key); hf != nil {
+				hf.Field, hf.Exp = name, ft.source()
+				healed = append(healed, hf)
+				hft := *ft
+				hft.Exp, hft.Label, hft.Role, hft.Name = hf.XPath, "", "", nil
+				getInfoByTag(etor.funcs, etor.doc, []*fieldtag{&hft}, v.Elem())
+				continue
+			}
+		}
+		if err == nil {
+			setValueByTag(ft, value, v.Elem())
+		}
+	}
+	return healed
+}
+
+func (healer *Healer) remember(key string, ft *fieldtag, nodes []*htmlquery.Node) {
+	if ft.Kind != reflect.Slice {
+		if ft.VIndex != -1 && ft.VIndex < len(nodes) {
+			nodes = nodes[ft.VIndex : ft.VIndex+1]
+		} else {
+			nodes = nodes[:1]
+		}
+	}
+	if len(nodes) > maxRememberNodes {
+		nodes = nodes[:maxRememberNodes]
+	}
+
+	mem := &fieldMemory{}
+	if nodes[0].IsAttribute() {
+		mem.attr = nodes[0].Data
+	}
+	for i, n := range nodes {
+		elem := n
+		if n.IsAttribute() {
+			if n.Data != mem.attr {
+				return
+			}
+			elem = n.GetParent()
+		} else if !n.IsElement() {
+			return // 文本节点等不记住
+		}
+		f := newNodeFeature(elem, n.Text())
+		if i == 0 {
+			f.paths = append(f.paths, elem.RobustXPath()) // 只有第一个节点, RobustXPath 需要多次查询
+		}
+		f.paths = append(f.paths, elem.XPath())
+		mem.nodes = append(mem.nodes, f)
+	}
+
+	healer.mu.Lock()
+	healer.fields[key] = mem
+	healer.mu.Unlock()
+}
+
+// heal 为每个记住的节点找最匹配的节点, 再生成选择这些节点的表达式
+func (healer *Healer) heal(etor *HmtlExtractor, key string) *HealedField {
+	healer.mu.Lock()
+	mem := healer.fields[key]
+	healer.mu.Unlock()
+	if mem == nil {
+		return nil
+	}
+
+	var elements []*htmlquery.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if n.Data == "script" || n.Data == "style" {
+				return
+			}
+			if node := (*htmlquery.Node)(n); mem.attr == "" || node.GetAttributeByKey(mem.attr) != nil {
+				elements = append(elements, node)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk((*html.Node)(etor.doc))
+
+	// 每个元素的特征只计算一次
+	features := make([]nodeFeature, len(elements))
+	index := make(map[*htmlquery.Node]int, len(elements))
+	for i, n := range elements {
+		value := n.Text()
+		if mem.attr != "" {
+			value, _ = n.AttributeValue(mem.attr)
+		}
+		features[i] = newNodeFeature(n, value)
+		index[n] = i
+	}
+
+	var found []*htmlquery.Node
+	var total float64
+	used := make(map[*htmlquery.Node]bool)
+	for _, f := range mem.nodes {
+		var best *htmlquery.Node
+		var bestScore float64
+		// 先试记住的路径, 足够相似就不用比较所有的元素
+		for _, path := range f.paths {
+			if n, err := etor.doc.Query(path); err == nil && n != nil && !used[n] {
+				if i, ok := index[n]; ok {
+					if s := f.score(features[i]); s >= HealThreshold {
+						best, bestScore = n, s
+						break
+					}
+				}
+			}
+		}
+		if best == nil {
+			for i, n := range elements {
+				if used[n] {
+					continue
+				}
+				if s := f.score(features[i]); s > bestScore {
+					best, bestScore = n, s
+				}
+			}
+		}
+		if best != nil && bestScore >= HealThreshold {
+			used[best] = true
+			found = append(found, best)
+			total += bestScore
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	found = htmlquery.DocumentOrder(found)
+
+	if mem.attr != "" {
+		for i, n := range found {
+			found[i], _ = n.Query("@" + mem.attr)
+		}
+	}
+
+	var exps []string
+	if len(found) == 1 {
+		exps = []string{found[0].RobustXPath()}
+	} else {
+		exps = generalizeXPaths(found)
+	}
+	for _, exp := range exps {
+		s := etor.newSuggestion(exp, 0)
+		if s != nil && newXPath(s.nodes...).Contains(newXPath(found...)) {
+			return &HealedField{XPath: exp, Values: s.Values, Score: total / float64(len(found))}
+		}
+	}
+	return nil
+}
+
+func newNodeFeature(n *htmlquery.Node, value string) nodeFeature {
+	f := nodeFeature{
+		value:   htmlquery.NormalizeSpace(value),
+		tag:     n.Data,
+		classes: strings.Fields(attrValue(n, "class")),
+	}
+	for _, attr := range n.Attr {
+		f.attrKeys = append(f.attrKeys, attr.Key)
+	}
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if label := htmlquery.NormalizeSpace((*htmlquery.Node)(s).Text()); label != "" {
+			if runes := []rune(label); len(runes) > 40 {
+				label = string(runes[len(runes)-40:])
+			}
+			f.label = label
+			break
+		}
+	}
+	if p := n.GetParent(); p != nil && p.Type == html.ElementNode {
+		f.parentTag = p.Data
+		f.parentClasses = strings.Fields(attrValue(p, "class"))
+	}
+	return f
+}
+
+// score 内容和结构的相似度 0 ~ 1
+func (f *nodeFeature) score(o nodeFeature) float64 {
+	s := 0.1*jaccard(f.classes, o.classes) + 0.05*jaccard(f.attrKeys, o.attrKeys) + 0.05*jaccard(f.parentClasses, o.parentClasses)
+	if f.value != "" {
+		s += 0.45 * matchScore(o.value, f.value)
+	}
+	if f.tag == o.tag {
+		s += 0.15
+	}
+	if f.label != "" && f.label == o.label {
+		s += 0.15
+	}
+	if f.parentTag == o.parentTag {
+		s += 0.05
+	}
+	return s
+}
+
+// jaccard the similarity of two sets. two empty sets are same
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	set := make(map[string]bool, len(a))
+	for _, s := range a {
+		set[s] = true
+	}
+	var inter int
+	union := len(set)
+	seen := make(map[string]bool, len(b))
+	for _, s := range b {
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		if set[s] {
+			inter++
+		} else {
+			union++
+		}
+	}
+	return float64(inter) / float64(union)
+}
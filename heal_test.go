@@ -0,0 +1,120 @@
+package extractor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+type healProduct struct {
+	Title string   `exp:"//h1[@class='title']"`
+	Price string   `exp:"//b[@class='price']"`
+	Link  string   `exp:"//a[@class='buy']/@href"`
+	Tags  []string `exp:"//ul[@class='tags']/li"`
+	Count int      `exp:"count(//ul/li)"`
+}
+
+func TestGetObjectByTagWithHealer(t *testing.T) {
+	healer := NewHealer()
+
+	v1 := ExtractHtmlString(`<html><body>
+		<h1 class="title">Red Shoes</h1>
+		<div class="box"><span>Price:</span> <b class="price">$10</b></div>
+		<a class="buy" href="/buy/1">buy</a>
+		<ul class="tags"><li>shoes</li><li>red</li></ul>
+	</body></html>`)
+	var p1 healProduct
+	if healed := v1.GetObjectByTagWithHealer(&p1, healer); len(healed) != 0 {
+		t.Error(healed)
+	}
+	if p1.Title != "Red Shoes" || p1.Price != "$10" || p1.Link != "/buy/1" || fmt.Sprint(p1.Tags) != "[shoes red]" || p1.Count != 2 {
+		t.Error(p1)
+	}
+
+	// 改版后的页面, class 都改了
+	v2 := ExtractHtmlString(`<html><body>
+		<h1 class="product-name">Red Shoes</h1>
+		<div class="box"><span>Price:</span> <b class="cost">$12</b></div>
+		<a class="btn-buy" href="/buy/1">buy</a>
+		<ul class="labels"><li>shoes</li><li>red</li><li>new</li></ul>
+	</body></html>`)
+	var p2 healProduct
+	healed := v2.GetObjectByTagWithHealer(&p2, healer)
+	if p2.Title != "Red Shoes" || p2.Price != "$12" || p2.Link != "/buy/1" || fmt.Sprint(p2.Tags) != "[shoes red new]" || p2.Count != 3 {
+		t.Error(p2)
+	}
+	if len(healed) != 4 {
+		t.Fatal(healed)
+	}
+	for _, hf := range healed {
+		xp, err := v2.XPath(hf.XPath)
+		if err != nil || fmt.Sprint(xp.GetTexts()) != fmt.Sprint(hf.Values) || hf.Score < HealThreshold {
+			t.Error(hf, err)
+		}
+	}
+	if healed[1].Field != "Price" || healed[1].Exp != "//b[@class='price']" {
+		t.Error(healed[1])
+	}
+
+	// 没有记忆的不修复
+	var p3 healProduct
+	if healed := v2.GetObjectByTagWithHealer(&p3, NewHealer()); len(healed) != 0 || p3.Title != "" {
+		t.Error(healed, p3)
+	}
+}
+
+type healLabelProduct struct {
+	Price string `label:"Price"`
+	Buy   string `role:"button" name:"Buy" mth:"AttrValue,data-sku"`
+}
+
+func TestHealLabelAndRole(t *testing.T) {
+	healer := NewHealer()
+	v1 := ExtractHtmlString(`<html><body>
+		<dl><dt>Price</dt><dd class="price">$10</dd></dl>
+		<button class="buy" data-sku="A1">Buy</button>
+	</body></html>`)
+	var p1 healLabelProduct
+	if healed := v1.GetObjectByTagWithHealer(&p1, healer); len(healed) != 0 || p1.Price != "$10" || p1.Buy != "A1" {
+		t.Error(healed, p1)
+	}
+	if len(healer.fields) != 2 {
+		t.Fatal(healer.fields)
+	}
+
+	// 标签和按钮的文本都改了
+	v2 := ExtractHtmlString(`<html><body>
+		<dl><dt>Cost</dt><dd class="price">$10</dd></dl>
+		<button class="buy" data-sku="A2">Buy now</button>
+	</body></html>`)
+	var p2 healLabelProduct
+	healed := v2.GetObjectByTagWithHealer(&p2, healer)
+	if len(healed) != 2 || p2.Price != "$10" || p2.Buy != "A2" {
+		t.Fatal(healed, p2)
+	}
+	if healed[0].Exp != `label:"Price"` || healed[1].Exp != `role:"button" name:"Buy"` {
+		t.Error(healed[0].Exp, healed[1].Exp)
+	}
+}
+
+func TestHealNodeFeature(t *testing.T) {
+	e := ExtractHtmlString(`<html><body><div><span>` + strings.Repeat("价格", 30) + `</span><b class="price">10元</b></div></body></html>`)
+	b := e.doc.FindOne("//b")
+	f := newNodeFeature(b, b.Text())
+	if !utf8.ValidString(f.label) || utf8.RuneCountInString(f.label) != 40 {
+		t.Error(f.label)
+	}
+
+	// 记住节点的路径, 修复的时候先试
+	healer := NewHealer()
+	var p struct {
+		Price string `exp:"//b[@class='price']"`
+	}
+	e.GetObjectByTagWithHealer(&p, healer)
+	for _, mem := range healer.fields {
+		if fmt.Sprint(mem.nodes[0].paths) != "[//b /html[1]/body[1]/div[1]/b[1]]" {
+			t.Error(mem.nodes[0].paths)
+		}
+	}
+}
@@ -3,10 +3,13 @@ package extractor
 import (
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/474420502/extractor/htmlquery"
 	"github.com/davecgh/go-spew/spew"
 )
 
@@ -416,3 +419,235 @@ func TestAutoValueType(t *testing.T) {
 		}
 	}
 }
+
+type scalarObject struct {
+	Count    int     `exp:"count(//li)"`
+	Title    string  `exp:"string(//title)"`
+	Total    float64 `exp:"sum(//li/@data-price)"`
+	SoldOut  bool    `exp:"boolean(//div[@class='sold-out'])"`
+	OnSale   bool    `exp:"boolean(//div[@class='on-sale'])"`
+	Counts   []int   `exp:"count(//li[@data-price > 10])"`
+	PriceNum float64 `exp:"string(//li[2]/@data-price)" mth:"r:ParseNumber"`
+}
+
+func TestEvaluate(t *testing.T) {
+	e := ExtractHtmlString(`<html><head><title>Shop</title></head><body>
+		<ul><li data-price="10">a</li><li data-price="20.5">b</li><li data-price="30">c</li></ul>
+		<div class="sold-out"></div>
+	</body></html>`)
+
+	if v, err := e.Evaluate("count(//li)"); err != nil || v.(float64) != 3 {
+		t.Error(v, err)
+	}
+
+	if v, err := e.EvaluateInt("count(//li)"); err != nil || v != 3 {
+		t.Error(v, err)
+	}
+
+	if v, err := e.EvaluateString("string(//title)"); err != nil || v != "Shop" {
+		t.Error(v, err)
+	}
+
+	if v, err := e.EvaluateString("//li"); err != nil || v != "a" {
+		t.Error(v, err)
+	}
+
+	if v, err := e.EvaluateNumber("sum(//li/@data-price)"); err != nil || v != 60.5 {
+		t.Error(v, err)
+	}
+
+	if v, err := e.EvaluateBool("boolean(//div[@class='sold-out'])"); err != nil || !v {
+		t.Error(v, err)
+	}
+
+	if v, err := e.Evaluate("//li"); err != nil || len(v.([]*htmlquery.Node)) != 3 {
+		t.Error(v, err)
+	}
+
+	if _, err := e.Evaluate("count(//li"); err == nil {
+		t.Error("err should not be nil")
+	}
+
+	o := &scalarObject{}
+	e.GetObjectByTag(o)
+	if o.Count != 3 || o.Title != "Shop" || o.Total != 60.5 || !o.SoldOut || o.OnSale {
+		t.Error(spew.Sdump(o))
+	}
+
+	if fmt.Sprint(o.Counts) != "[2]" || o.PriceNum != 20.5 {
+		t.Error(spew.Sdump(o))
+	}
+}
+
+type nanObject struct {
+	Title  int     `exp:"string(//title)"`
+	Div    uint32  `exp:"sum(//p) div 0"`
+	Counts []int64 `exp:"number(//title)"`
+	NaN    float64 `exp:"string(//title)"`
+}
+
+func TestEvaluateNaN(t *testing.T) {
+	e := ExtractHtmlString(`<html><head><title>abc</title></head><body><p>1</p><p>2</p></body></html>`)
+
+	if v, err := e.EvaluateInt("string(//title)"); err == nil || v != 0 {
+		t.Error(v, err)
+	}
+	if v, err := e.EvaluateInt("sum(//p) div 0"); err == nil || v != 0 {
+		t.Error(v, err)
+	}
+	if v, err := e.EvaluateInt("sum(//p)"); err != nil || v != 3 {
+		t.Error(v, err)
+	}
+
+	o := &nanObject{}
+	e.GetObjectByTag(o)
+	if o.Title != 0 || o.Div != 0 || fmt.Sprint(o.Counts) != "[0]" || !math.IsNaN(o.NaN) {
+		t.Error(spew.Sdump(o))
+	}
+}
+
+type funcObject struct {
+	Buttons []string `exp:"//a[has-class('btn')]"`
+	Cheap   []string `exp:"//li[price(.) < 20]"`
+}
+
+func TestRegisterFunction(t *testing.T) {
+	html := `<html><body>
+		<a class="btn x">a</a><a class="btnx">b</a><a class="btn">c</a>
+		<ul><li>10 USD</li><li>25 USD</li><li>3 USD</li></ul>
+	</body></html>`
+	e := ExtractHtmlString(html)
+	e.RegisterFunction("price", htmlquery.FuncNumber, func(ctx *htmlquery.Node, args ...interface{}) interface{} {
+		v, _ := ParseNumber(strings.TrimSuffix(htmlquery.StringValue(args[0]), " USD"))
+		return v
+	})
+
+	o := &funcObject{}
+	e.GetObjectByTag(o)
+	if fmt.Sprint(o.Buttons) != "[a c]" || fmt.Sprint(o.Cheap) != "[10 USD 3 USD]" {
+		t.Error(spew.Sdump(o))
+	}
+
+	xp, err := e.XPath("//ul")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if texts, errs := xp.ForEachText("./li[price(.) > 20]"); len(errs) > 0 || fmt.Sprint(texts) != "[25 USD]" {
+		t.Error(texts, errs)
+	}
+
+	// 其他提取器不受影响
+	if _, err := ExtractHtmlString(html).XPath("//li[price(.) < 20]"); err == nil {
+		t.Error("price() should be scoped to the extractor")
+	}
+}
+
+func TestXPathWithVars(t *testing.T) {
+	e := ExtractHtmlString(`<html><body>
+		<div class="item" data-kw="go"><a>x</a><a data-id="o'neil">y</a></div>
+		<div class="item" data-kw="rust"><a data-id="o'neil">z</a></div>
+	</body></html>`)
+
+	xp, err := e.XPathWithVars("//div[@data-kw=$kw]", map[string]interface{}{"kw": "go"})
+	if err != nil || fmt.Sprint(xp.GetTexts()) != "[xy]" {
+		t.Error(xp.GetTexts(), err)
+	}
+
+	xp, _ = e.XPath("//div[@class='item']")
+	as, errs := xp.ForEachWithVars(".//a[@data-id=$id]", map[string]interface{}{"id": "o'neil"})
+	if len(errs) > 0 || fmt.Sprint(as.GetTexts()) != "[y z]" {
+		t.Error(as.GetTexts(), errs)
+	}
+
+	if _, err := e.XPathWithVars("//div[@data-kw=$kw]", nil); err == nil {
+		t.Error("err should not be nil")
+	}
+}
+
+func TestAttributeXPath(t *testing.T) {
+	e := ExtractHtmlString(`<html><body><a class="c1" href="/1">a</a><a class="c2" href="/2">b</a></body></html>`)
+	xp, _ := e.XPath("//a/@href")
+
+	if names := xp.GetTagNames(); len(names) != 0 {
+		t.Error(names)
+	}
+
+	if texts := xp.GetTexts(); fmt.Sprint(texts) != "[/1 /2]" {
+		t.Error(texts)
+	}
+
+	if classes := xp.Parent().GetAttrValuesByKey("class"); fmt.Sprint(classes) != "[c1 c2]" {
+		t.Error(classes)
+	}
+
+	if texts, errs := xp.ForEachText(".."); len(errs) > 0 || fmt.Sprint(texts) != "[a b]" {
+		t.Error(texts, errs)
+	}
+
+	all, _ := e.XPath("//a[1]/@href | //@href")
+	if all.Len() != 2 || all.Intersect(xp).Len() != 2 {
+		t.Error(all.GetTexts())
+	}
+}
+
+type visibleProduct struct {
+	Title string `exp:"//h1" mth:"VisibleText"`
+	Price string `exp:"//span[@class='price']" mth:"VisibleText"`
+}
+
+func TestVisibleText(t *testing.T) {
+	e := ExtractHtmlString(`<html><body>
+		<h1>Shoes<span class="sr-only"> (on sale)</span></h1>
+		<span class="price"> <s style="display:none">$12</s>$10 </span>
+	</body></html>`)
+
+	var p visibleProduct
+	e.GetObjectByTag(&p)
+	if p.Title != "Shoes" || strings.TrimSpace(p.Price) != "$10" {
+		t.Errorf("%#v", p)
+	}
+
+	xp, _ := e.XPath("//h1 | //span[@class='price']")
+	if texts := xp.GetVisibleTexts(); fmt.Sprint(texts) != "[Shoes  $10 ]" {
+		t.Errorf("%q", texts)
+	}
+}
+
+type htmlFormatProduct struct {
+	Outer  string `exp:"//div[@class='desc']" mth:"OuterHTML"`
+	Inner  string `exp:"//div[@class='desc']" mth:"InnerHTML"`
+	Pretty string `exp:"//div[@class='desc']" mth:"PrettyHTML"`
+	Mini   string `exp:"//div[@class='desc']" mth:"FormatHTML,inner,minify"`
+}
+
+func TestHTMLFormats(t *testing.T) {
+	e := ExtractHtmlString(`<html><body><div class="desc">
+		<p>Soft   <b>cotton</b></p>
+		<!-- note -->
+		<ul><li>S</li><li>M</li></ul>
+	</div></body></html>`)
+
+	var p htmlFormatProduct
+	e.GetObjectByTag(&p)
+	if !strings.HasPrefix(p.Outer, `<div class="desc">`) || !strings.HasPrefix(strings.TrimSpace(p.Inner), "<p>") {
+		t.Errorf("%#v", p)
+	}
+	if p.Pretty != "<div class=\"desc\">\n  <p>Soft <b>cotton</b></p>\n  <!-- note -->\n  <ul>\n    <li>S</li>\n    <li>M</li>\n  </ul>\n</div>" {
+		t.Error(p.Pretty)
+	}
+	if p.Mini != "<p>Soft <b>cotton</b><ul><li>S<li>M</ul>" {
+		t.Error(p.Mini)
+	}
+
+	xp, _ := e.XPath("//li")
+	if got := xp.GetStrings(); fmt.Sprint(got) != "[<li>S</li> <li>M</li>]" {
+		t.Error(got)
+	}
+	if got := xp.GetStrings(htmlquery.HTMLInner); fmt.Sprint(got) != "[S M]" {
+		t.Error(got)
+	}
+	ul, _ := e.XPath("//ul")
+	if got := ul.GetStrings(htmlquery.HTMLInner, htmlquery.HTMLMinify); fmt.Sprint(got) != "[<li>S<li>M]" {
+		t.Error(got)
+	}
+}
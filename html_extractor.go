@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -19,7 +20,8 @@ import (
 type HmtlExtractor struct {
 	content []byte
 	// doc     types.Document
-	doc *htmlquery.Node
+	doc   *htmlquery.Node
+	funcs *htmlquery.Functions // xpath 扩展函数, 只作用于当前的提取器
 }
 
 // ExtractHtmlString extractor xml(html)
@@ -36,6 +38,7 @@ func ExtractHtml(content []byte) *HmtlExtractor {
 	e := &HmtlExtractor{}
 	e.doc = doc
 	e.content = content
+	e.funcs = htmlquery.NewFunctions()
 	return e
 }
 
@@ -66,13 +69,81 @@ func (etor *HmtlExtractor) GetObjectByTag(obj interface{}) {
 		log.Panic("obj must ptr")
 	}
 	vtype = vtype.Elem()
-	getInfoByTag(etor.doc, getFieldTags(vtype), v.Elem())
+	getInfoByTag(etor.funcs, etor.doc, getFieldTags(vtype), v.Elem())
+}
+
+// RegisterFunction register the go function to the xpath expressions of the extractor(include tags).
+// eg: etor.RegisterFunction("price", htmlquery.FuncNumber, fn) then exp:"//li[price(.) > 10]"
+func (etor *HmtlExtractor) RegisterFunction(name string, typ htmlquery.FuncType, call htmlquery.FunctionCall) {
+	etor.funcs.Register(name, typ, call)
+}
+
+// Functions the xpath functions of the extractor
+func (etor *HmtlExtractor) Functions() *htmlquery.Functions {
+	return etor.funcs
 }
 
 // XPaths multi xpath extractor
 func (etor *HmtlExtractor) XPath(exp string) (*XPath, error) {
-	result, err := etor.doc.QueryAll(exp)
-	return newXPath(result...), err
+	result, err := etor.funcs.QueryAll(etor.doc, exp)
+	xp := newXPath(result...)
+	xp.funcs = etor.funcs
+	return xp, err
+}
+
+// XPathWithVars xpath with variables. eg: XPathWithVars("//a[@data-id=$id]", map[string]interface{}{"id": v})
+// the values are bound safely as xpath string number or boolean, not concatenated to the expression
+func (etor *HmtlExtractor) XPathWithVars(exp string, vars map[string]interface{}) (*XPath, error) {
+	result, err := etor.funcs.QueryAllWithVars(etor.doc, exp, vars)
+	xp := newXPath(result...)
+	xp.funcs = etor.funcs
+	return xp, err
+}
+
+// Evaluate evaluates xpath expression. the result is one of float64, string, bool
+// (count() sum() string() boolean() ...) or []*htmlquery.Node if exp is node-set expression
+func (etor *HmtlExtractor) Evaluate(exp string) (interface{}, error) {
+	return etor.funcs.Evaluate(etor.doc, exp)
+}
+
+// EvaluateString evaluates xpath expression and converts the result to string like xpath string()
+func (etor *HmtlExtractor) EvaluateString(exp string) (string, error) {
+	v, err := etor.Evaluate(exp)
+	if err != nil {
+		return "", err
+	}
+	return htmlquery.StringValue(v), nil
+}
+
+// EvaluateNumber evaluates xpath expression and converts the result to float64 like xpath number()
+func (etor *HmtlExtractor) EvaluateNumber(exp string) (float64, error) {
+	v, err := etor.Evaluate(exp)
+	if err != nil {
+		return 0, err
+	}
+	return htmlquery.NumberValue(v), nil
+}
+
+// EvaluateInt evaluates xpath expression and converts the result to int. eg: count(//li)
+// NaN(eg: number('abc')) or Inf(eg: 1 div 0) returns 0 and the error
+func (etor *HmtlExtractor) EvaluateInt(exp string) (int, error) {
+	f, err := etor.EvaluateNumber(exp)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("%s: %v can not convert to int", exp, f)
+	}
+	return int(f), nil
+}
+
+// EvaluateBool evaluates xpath expression and converts the result to bool like xpath boolean()
+func (etor *HmtlExtractor) EvaluateBool(exp string) (bool, error) {
+	v, err := etor.Evaluate(exp)
+	if err != nil {
+		return false, err
+	}
+	return htmlquery.BoolValue(v), nil
 }
 
 // ErrorFlags  忽略错误标志位, 暂时不用
@@ -89,10 +160,12 @@ const (
 type XPath struct {
 	results    []*htmlquery.Node
 	errorFlags ErrorFlags
+	funcs      *htmlquery.Functions // nil is the builtin functions
 }
 
+// newXPath the results are in document order without duplicate nodes
 func newXPath(result ...*htmlquery.Node) *XPath {
-	xp := &XPath{results: result, errorFlags: ErrorSkip}
+	xp := &XPath{results: htmlquery.DocumentOrder(result), errorFlags: ErrorSkip}
 	return xp
 }
 
@@ -101,11 +174,16 @@ func (xp *XPath) GetXPathResults() []*htmlquery.Node {
 	return xp.results
 }
 
-// GetStrings Get Current XPath Results
-func (xp *XPath) GetStrings() []string {
+// GetStrings Get the html of Current XPath Results. the formats are combined, default is the outer html.
+// eg: GetStrings(htmlquery.HTMLInner | htmlquery.HTMLMinify)
+func (xp *XPath) GetStrings(format ...htmlquery.HTMLFormat) []string {
+	var f htmlquery.HTMLFormat
+	for _, v := range format {
+		f |= v
+	}
 	var ret []string
 	for _, xresult := range xp.results {
-		ret = append(ret, xresult.OutputHTML(true))
+		ret = append(ret, xresult.RenderHTML(f))
 	}
 	return ret
 }
@@ -173,6 +251,19 @@ func (xp *XPath) GetTexts() []string {
 	return txts
 }
 
+// GetVisibleTexts Get the visible Text of the Current XPath Results. the hidden nodes are skipped(see htmlquery.Node.IsHidden)
+func (xp *XPath) GetVisibleTexts() []string {
+	if len(xp.results) == 0 {
+		return nil
+	}
+
+	var txts []string
+	for _, xpresult := range xp.results {
+		txts = append(txts, xpresult.VisibleText())
+	}
+	return txts
+}
+
 // GetTagNames Get the NodeValue of the Current XPath Results
 func (xp *XPath) GetTagNames() []string {
 	if len(xp.results) == 0 {
@@ -208,11 +299,28 @@ type fieldtag struct {
 	MIndex int          // method results selected index
 	Index  int          // index
 	Exp    string       // expression 表达式
+	Label  string       // label 标签文本, 取标签旁边的值. eg: Price|価格
+	Role   string       // role ARIA角色. eg: button
+	Name   *string      // name 角色的accessible name
 	// Method string
 	// Args   []reflect.Value
 	Methods []methodtag // multi method 多个方法
 }
 
+// source 字段的查询方式, 用于报告. eg: //h1 label:"Price" role:"button" name:"Buy"
+func (ft *fieldtag) source() string {
+	switch {
+	case ft.Role != "":
+		if ft.Name != nil {
+			return fmt.Sprintf("role:%q name:%q", ft.Role, *ft.Name)
+		}
+		return fmt.Sprintf("role:%q", ft.Role)
+	case ft.Label != "":
+		return fmt.Sprintf("label:%q", ft.Label)
+	}
+	return ft.Exp
+}
+
 // DefaultMethod 默认函数 如果tag没写mth(method) 的标识. 默认就是call Text()
 var DefaultMethod = "Text"
 
@@ -247,10 +355,18 @@ func getFieldTags(otype reflect.Type) []*fieldtag {
 
 		f := otype.Field(i)
 		// 获取表达式 TODO: 转义之类的支持 正则之类的支持. json之类的支持 ...
-		if exp, ok := f.Tag.Lookup("exp"); ok {
+		exp, ok := f.Tag.Lookup("exp")
+		label, lok := f.Tag.Lookup("label")
+		role, rok := f.Tag.Lookup("role")
+		if ok || lok || rok {
 			ft := &fieldtag{}
 			ft.Index = i
 			ft.Exp = exp
+			ft.Label = label
+			ft.Role = role
+			if name, ok := f.Tag.Lookup("name"); ok {
+				ft.Name = &name
+			}
 			ft.Kind = f.Type.Kind()
 			ft.Type = otype
 
@@ -513,6 +629,13 @@ func autoValueType(vtype string, v interface{}) reflect.Value {
 		default:
 			panic(fmt.Errorf("%s, %s", rv, v))
 		}
+	case "bool":
+		switch rv := v.(type) {
+		case bool:
+			return reflect.ValueOf(rv)
+		default:
+			return reflect.ValueOf(autoValueType("float64", v).Float() != 0)
+		}
 	case "string":
 		panic("type is string")
 	default:
@@ -586,6 +709,12 @@ func autoStrToValueByType(ft *fieldtag, fvalue reflect.Value) reflect.Value {
 			log.Println(err)
 		}
 		return reflect.ValueOf(v)
+	case "bool":
+		v, err := strconv.ParseBool(fvalue.Interface().(string))
+		if err != nil {
+			log.Println(err)
+		}
+		return reflect.ValueOf(v)
 	case "string":
 		return fvalue
 	default:
@@ -633,79 +762,152 @@ func callMethod(becall reflect.Value, method *methodtag) []reflect.Value {
 	return nil
 }
 
-func getInfoByTag(node *htmlquery.Node, fieldtags []*fieldtag, obj reflect.Value) {
-	var ft *fieldtag
+func getInfoByTag(funcs *htmlquery.Functions, node *htmlquery.Node, fieldtags []*fieldtag, obj reflect.Value) {
+	for _, ft := range fieldtags {
+		if value, err := evaluateTag(funcs, node, ft); err == nil {
+			setValueByTag(ft, value, obj)
+		}
+	}
+}
+
+// evaluateTag 按 role label exp 的顺序查询字段的结果
+func evaluateTag(funcs *htmlquery.Functions, node *htmlquery.Node, ft *fieldtag) (interface{}, error) {
+	switch {
+	case ft.Role != "":
+		var names []string
+		if ft.Name != nil {
+			names = append(names, *ft.Name)
+		}
+		return findByRole(node, ft.Role, names...), nil
+	case ft.Label != "":
+		return findLabelValues(node, ft.Label), nil
+	default:
+		return funcs.Evaluate(node, ft.Exp)
+	}
+}
+
+// setValueByTag 查询的结果调用方法后赋值给字段
+func setValueByTag(ft *fieldtag, value interface{}, obj reflect.Value) {
 	defer func() {
 		if err := recover(); err != nil {
 			log.Panicf("err is %s\n fieldtags is %#v", err, ft)
 		}
 	}()
 
-	for _, ft = range fieldtags {
-		result, err := node.QueryAll(ft.Exp)
-		if err == nil {
-			if ft.Kind == reflect.Slice { // 如果是Slice 就返回Slice
-				var callresults [][]reflect.Value
-				for _, n := range result {
-					becall := reflect.ValueOf(n)
-					var isVaild = true
-					var callresult []reflect.Value
-					for _, method := range ft.Methods {
-						if !becall.IsNil() {
-							callresult = callMethod(becall, &method)
-							becall = callresult[0]
-						} else {
-							isVaild = false
-							break
-						}
-					}
+	result, ok := value.([]*htmlquery.Node)
+	if !ok { // count() sum() string() boolean() 等标量表达式
+		setScalarByTag(ft, value, obj)
+		return
+	}
 
-					if isVaild {
-						callresults = append(callresults, callresult)
-					}
+	if ft.Kind == reflect.Slice { // 如果是Slice 就返回Slice
+		var callresults [][]reflect.Value
+		for _, n := range result {
+			becall := reflect.ValueOf(n)
+			var isVaild = true
+			var callresult []reflect.Value
+			for _, method := range ft.Methods {
+				if !becall.IsNil() {
+					callresult = callMethod(becall, &method)
+					becall = callresult[0]
+				} else {
+					isVaild = false
+					break
 				}
+			}
 
-				if len(callresults) > 0 {
-					fvalue := obj.Field(ft.Index)
-					for _, callresult := range callresults {
-						fvalue = reflect.Append(fvalue, autoStrToValueByType(ft, callresult[0]))
-					}
-					obj.Field(ft.Index).Set(fvalue)
-				}
+			if isVaild {
+				callresults = append(callresults, callresult)
+			}
+		}
 
-			} else {
+		if len(callresults) > 0 {
+			fvalue := obj.Field(ft.Index)
+			for _, callresult := range callresults {
+				fvalue = reflect.Append(fvalue, autoStrToValueByType(ft, callresult[0]))
+			}
+			obj.Field(ft.Index).Set(fvalue)
+		}
 
-				if len(result) > 0 {
-					var selResult *htmlquery.Node
-					if ft.VIndex != -1 {
-						selResult = result[ft.VIndex]
-					} else {
-						selResult = result[0]
-					}
+	} else {
 
-					var isVaild = true
-					becall := reflect.ValueOf(selResult)
-					var callresult []reflect.Value
-					for _, method := range ft.Methods {
-						if !becall.IsNil() {
-							callresult = callMethod(becall, &method)
-							becall = callresult[0]
-						} else {
-							isVaild = false
-							break
-						}
+		if len(result) > 0 {
+			var selResult *htmlquery.Node
+			if ft.VIndex != -1 {
+				selResult = result[ft.VIndex]
+			} else {
+				selResult = result[0]
+			}
 
-						if isVaild {
-							fvalue := callresult[0]
-							obj.Field(ft.Index).Set(autoStrToValueByType(ft, fvalue))
-						}
-					}
+			var isVaild = true
+			becall := reflect.ValueOf(selResult)
+			var callresult []reflect.Value
+			for _, method := range ft.Methods {
+				if !becall.IsNil() {
+					callresult = callMethod(becall, &method)
+					becall = callresult[0]
+				} else {
+					isVaild = false
+					break
+				}
+
+				if isVaild {
+					fvalue := callresult[0]
+					obj.Field(ft.Index).Set(autoStrToValueByType(ft, fvalue))
 				}
 			}
 		}
 	}
 }
 
+// isIntegerType vtype 是整数类型
+func isIntegerType(vtype string) bool {
+	switch vtype {
+	case "int", "int32", "int64", "uint", "uint32", "uint64":
+		return true
+	}
+	return false
+}
+
+// setScalarByTag 标量表达式的结果赋值. 如果有注册函数(r:) 以xpath string()的结果调用
+func setScalarByTag(ft *fieldtag, value interface{}, obj reflect.Value) {
+	var fvalue reflect.Value
+	for _, method := range ft.Methods {
+		if method.IsRegister {
+			if !fvalue.IsValid() {
+				fvalue = reflect.ValueOf(htmlquery.StringValue(value))
+			}
+			fvalue = callMethod(fvalue, &method)[0]
+		} else if method.Method != DefaultMethod && method.Method != string(String) {
+			log.Panicln(method.Method, "can not call on the scalar result of", ft.Exp)
+		}
+	}
+
+	if !fvalue.IsValid() {
+		switch ft.VType {
+		case "string":
+			fvalue = reflect.ValueOf(htmlquery.StringValue(value))
+		case "bool":
+			fvalue = reflect.ValueOf(htmlquery.BoolValue(value))
+		default:
+			f := htmlquery.NumberValue(value)
+			if (math.IsNaN(f) || math.IsInf(f, 0)) && isIntegerType(ft.VType) {
+				// 和节点的结果解析失败一样, 打印错误, 值为0
+				log.Println(ft.Exp, f, "can not convert to", ft.VType)
+				f = 0
+			}
+			fvalue = reflect.ValueOf(f)
+		}
+	}
+
+	field := obj.Field(ft.Index)
+	if ft.Kind == reflect.Slice {
+		field.Set(reflect.Append(field, autoStrToValueByType(ft, fvalue)))
+	} else {
+		field.Set(autoStrToValueByType(ft, fvalue))
+	}
+}
+
 // ForEachObjectByTag after every result executing xpath, get the String of all result
 func (xp *XPath) ForEachObjectByTag(obj interface{}) {
 	// oslice := reflect.ValueOf(obj)
@@ -723,7 +925,7 @@ func (xp *XPath) ForEachObjectByTag(obj interface{}) {
 	fieldtags = getFieldTags(otype)
 	for _, xpresult := range xp.results {
 		o := reflect.New(otype).Elem()
-		getInfoByTag(xpresult, fieldtags, o)
+		getInfoByTag(xp.funcs, xpresult, fieldtags, o)
 		if isTypePtr {
 			oslice = reflect.Append(oslice, o.Addr())
 		} else {
@@ -841,16 +1043,16 @@ func (xp *XPath) ForEachAttrValue(exp string, attributes ...string) (values []st
 	return values, errlist
 }
 
-// ForEachEx foreach after every result executing xpath do funciton. note: duplicate
+// ForEachEx foreach after every result executing xpath do funciton. note: duplicate nodes are removed, values are in document order
 func (xp *XPath) ForEachEx(exp string, do func(*htmlquery.Node) interface{}) (values []interface{}, errorlist []error) {
 	if len(xp.results) == 0 {
 		return
 	}
 
-	var dict map[uintptr]*htmlquery.Node = make(map[uintptr]*htmlquery.Node)
+	var nodes []*htmlquery.Node
 	for _, xpresult := range xp.results {
 
-		result, err := xpresult.QueryAll(exp)
+		result, err := xp.funcs.QueryAll(xpresult, exp)
 		var inodes []*htmlquery.Node
 		for _, qnode := range result {
 			inodes = append(inodes, qnode)
@@ -864,14 +1066,10 @@ func (xp *XPath) ForEachEx(exp string, do func(*htmlquery.Node) interface{}) (va
 			}
 		}
 
-		for _, n := range inodes {
-
-			dict[reflect.ValueOf(n).Pointer()] = n
-		}
-
+		nodes = append(nodes, inodes...)
 	}
 
-	for _, in := range dict {
+	for _, in := range htmlquery.DocumentOrder(nodes) {
 		if want := do(in); want != nil {
 			values = append(values, want)
 		}
@@ -882,13 +1080,18 @@ func (xp *XPath) ForEachEx(exp string, do func(*htmlquery.Node) interface{}) (va
 
 // ForEach new XPath( every result xpath get results ). note: not duplicate
 func (xp *XPath) ForEach(exp string) (newxpath *XPath, errorlist []error) {
+	return xp.ForEachWithVars(exp, nil)
+}
+
+// ForEachWithVars like ForEach, and binds the variables of the expression. see HmtlExtractor.XPathWithVars
+func (xp *XPath) ForEachWithVars(exp string, vars map[string]interface{}) (newxpath *XPath, errorlist []error) {
 	if len(xp.results) == 0 {
 		return
 	}
 
 	var results []*htmlquery.Node
 	for _, xpresult := range xp.results {
-		result, err := xpresult.QueryAll(exp)
+		result, err := xp.funcs.QueryAllWithVars(xpresult, exp, vars)
 		if err != nil {
 			if xp.errorFlags == ErrorSkip {
 				errorlist = append(errorlist, err)
@@ -898,6 +1101,6 @@ func (xp *XPath) ForEach(exp string) (newxpath *XPath, errorlist []error) {
 		}
 		results = append(results, result...)
 	}
-	newxpath = newXPath(results...)
+	newxpath = xp.derive(results)
 	return
 }
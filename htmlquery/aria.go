@@ -0,0 +1,271 @@
+package htmlquery
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Role the explicit(the first token of role attribute) or implicit ARIA role of the element. eg: button link heading.
+// empty if the element has no role
+func (n *Node) Role() string {
+	if n.Type != html.ElementNode {
+		return ""
+	}
+	if fields := strings.Fields(n.getAttr("role")); len(fields) > 0 {
+		return strings.ToLower(fields[0])
+	}
+
+	switch n.Data {
+	case "a", "area":
+		if n.hasAttr("href") {
+			return "link"
+		}
+	case "button":
+		return "button"
+	case "input":
+		return n.inputRole()
+	case "select":
+		if size, _ := strconv.Atoi(n.getAttr("size")); n.hasAttr("multiple") || size > 1 {
+			return "listbox"
+		}
+		return "combobox"
+	case "textarea":
+		return "textbox"
+	case "img":
+		if n.hasAttr("alt") && n.getAttr("alt") == "" {
+			return "presentation"
+		}
+		return "img"
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		return "heading"
+	case "ul", "ol", "menu":
+		return "list"
+	case "li":
+		return "listitem"
+	case "nav":
+		return "navigation"
+	case "main":
+		return "main"
+	case "aside":
+		return "complementary"
+	case "form":
+		return "form"
+	case "header", "footer":
+		// 在sectioning元素里面的没有landmark角色
+		for p := n.GetParent(); p != nil; p = p.GetParent() {
+			switch p.Data {
+			case "article", "aside", "main", "nav", "section":
+				return ""
+			}
+		}
+		if n.Data == "header" {
+			return "banner"
+		}
+		return "contentinfo"
+	case "section":
+		if n.hasAttr("aria-label") || n.hasAttr("aria-labelledby") {
+			return "region"
+		}
+	case "article":
+		return "article"
+	case "table":
+		return "table"
+	case "thead", "tbody", "tfoot":
+		return "rowgroup"
+	case "tr":
+		return "row"
+	case "td":
+		return "cell"
+	case "th":
+		return "columnheader"
+	case "dialog":
+		return "dialog"
+	case "progress":
+		return "progressbar"
+	case "option":
+		return "option"
+	case "fieldset", "details":
+		return "group"
+	case "hr":
+		return "separator"
+	case "output":
+		return "status"
+	case "p":
+		return "paragraph"
+	case "figure":
+		return "figure"
+	}
+	return ""
+}
+
+func (n *Node) inputRole() string {
+	switch strings.ToLower(n.getAttr("type")) {
+	case "button", "submit", "reset", "image":
+		return "button"
+	case "checkbox":
+		return "checkbox"
+	case "radio":
+		return "radio"
+	case "range":
+		return "slider"
+	case "number":
+		return "spinbutton"
+	case "hidden":
+		return ""
+	case "search":
+		if n.hasAttr("list") {
+			return "combobox"
+		}
+		return "searchbox"
+	default: // text email tel url ...
+		if n.hasAttr("list") {
+			return "combobox"
+		}
+		return "textbox"
+	}
+}
+
+// nameFromContent the roles which accessible name can be computed from the content
+var nameFromContent = map[string]bool{
+	"button": true, "cell": true, "checkbox": true, "columnheader": true, "gridcell": true, "heading": true,
+	"link": true, "menuitem": true, "option": true, "radio": true, "row": true, "rowheader": true,
+	"switch": true, "tab": true, "tooltip": true, "treeitem": true,
+}
+
+// AccessibleName the accessible name of the element, by aria-labelledby, aria-label, label[for], alt, value, legend, caption,
+// the content(for the roles like button link heading), title and placeholder. whitespace is normalized
+func (n *Node) AccessibleName() string {
+	if n.Type != html.ElementNode {
+		return ""
+	}
+
+	if ids := strings.Fields(n.getAttr("aria-labelledby")); len(ids) > 0 {
+		var parts []string
+		for _, id := range ids {
+			if ref := n.byID(id); ref != nil {
+				parts = append(parts, ref.nameText())
+			}
+		}
+		if name := NormalizeSpace(strings.Join(parts, " ")); name != "" {
+			return name
+		}
+	}
+	if name := NormalizeSpace(n.getAttr("aria-label")); name != "" {
+		return name
+	}
+
+	var name string
+	switch n.Data {
+	case "input", "select", "textarea":
+		typ := strings.ToLower(n.getAttr("type"))
+		switch {
+		case n.Data == "input" && (typ == "button" || typ == "submit" || typ == "reset"):
+			name = n.getAttr("value")
+			if name == "" && typ != "button" {
+				name = strings.ToUpper(typ[:1]) + typ[1:]
+			}
+		case n.Data == "input" && typ == "image":
+			name = n.getAttr("alt")
+		default:
+			name = n.labelText()
+		}
+	case "img", "area":
+		name = n.getAttr("alt")
+	case "fieldset":
+		name = n.childText("legend")
+	case "table":
+		name = n.childText("caption")
+	case "figure":
+		name = n.childText("figcaption")
+	}
+	if name = NormalizeSpace(name); name != "" {
+		return name
+	}
+
+	if nameFromContent[n.Role()] {
+		if name = NormalizeSpace(n.nameText()); name != "" {
+			return name
+		}
+	}
+	if name = NormalizeSpace(n.getAttr("title")); name != "" {
+		return name
+	}
+	return NormalizeSpace(n.getAttr("placeholder"))
+}
+
+// nameText 内容的文本, 图片取alt, 忽略隐藏的元素(see IsHidden)
+func (n *Node) nameText() string {
+	var sb strings.Builder
+	var walk func(c *html.Node)
+	walk = func(c *html.Node) {
+		switch c.Type {
+		case html.TextNode:
+			sb.WriteString(c.Data)
+			return
+		case html.ElementNode:
+			cn := (*Node)(c)
+			switch {
+			case cn.hidden():
+				return
+			case c.Data == "img" || c.Data == "area":
+				sb.WriteString(" " + cn.getAttr("alt") + " ")
+				return
+			case cn.hasAttr("aria-label") && c != (*html.Node)(n):
+				sb.WriteString(" " + cn.getAttr("aria-label") + " ")
+				return
+			}
+			sb.WriteString(" ")
+			defer sb.WriteString(" ")
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk((*html.Node)(n))
+	return NormalizeSpace(sb.String())
+}
+
+// labelText the text of <label for=id> or the label wrapping the control
+func (n *Node) labelText() string {
+	if id := n.getAttr("id"); id != "" {
+		if label, err := n.root().Query("//label[@for=" + XPathLiteral(id) + "]"); err == nil && label != nil {
+			return label.nameText()
+		}
+	}
+	for p := n.GetParent(); p != nil; p = p.GetParent() {
+		if p.Type == html.ElementNode && p.Data == "label" {
+			return p.nameText()
+		}
+	}
+	return ""
+}
+
+func (n *Node) childText(tag string) string {
+	for c := n.First(); c != nil; c = c.Next() {
+		if c.Type == html.ElementNode && c.Data == tag {
+			return c.nameText()
+		}
+	}
+	return ""
+}
+
+func (n *Node) byID(id string) *Node {
+	ref, err := n.root().Query("//*[@id=" + XPathLiteral(id) + "]")
+	if err != nil {
+		return nil
+	}
+	return ref
+}
+
+func (n *Node) hasAttr(key string) bool {
+	return n.GetAttributeByKey(key) != nil
+}
+
+func (n *Node) root() *Node {
+	for n.Parent != nil {
+		n = n.GetParent()
+	}
+	return n
+}
@@ -0,0 +1,62 @@
+package htmlquery
+
+import (
+	"testing"
+)
+
+func TestRoleAndAccessibleName(t *testing.T) {
+	doc := loadHTML(`<html><body>
+		<header><nav><a href="/">Home</a> <a>no href</a></nav></header>
+		<main>
+			<h2 id="t">Red <b>Shoes</b></h2>
+			<button id="b1"><img src="cart.png" alt="Add to"> cart</button>
+			<div role="button tab" aria-labelledby="t b1">custom</div>
+			<button aria-label="Close">×</button>
+			<label for="q">Search products</label><input id="q" type="search">
+			<label>Email <input id="e" type="email"></label>
+			<input type="submit">
+			<input type="text" placeholder="Your name" id="n">
+			<input type="hidden" id="h">
+			<img id="deco" src="x.png" alt="">
+			<table><caption>Prices</caption><tr><th>Name</th><td>Apple</td></tr></table>
+			<select id="s" size="3"></select>
+			<a href="/x" title="More info"></a>
+			<article><header id="ah">in article</header></article>
+		</main>
+		<footer>c</footer>
+	</body></html>`)
+
+	for _, c := range []struct {
+		expr, role, name string
+	}{
+		{"//body/header", "banner", ""},
+		{"//nav", "navigation", ""},
+		{"//nav/a[1]", "link", "Home"},
+		{"//nav/a[2]", "", ""},
+		{"//h2", "heading", "Red Shoes"},
+		{"//button[@id='b1']", "button", "Add to cart"},
+		{"//div[@role]", "button", "Red Shoes Add to cart"},
+		{"//button[@aria-label]", "button", "Close"},
+		{"//input[@id='q']", "searchbox", "Search products"},
+		{"//input[@id='e']", "textbox", "Email"},
+		{"//input[@type='submit']", "button", "Submit"},
+		{"//input[@id='n']", "textbox", "Your name"},
+		{"//input[@id='h']", "", ""},
+		{"//img[@id='deco']", "presentation", ""},
+		{"//table", "table", "Prices"},
+		{"//th", "columnheader", "Name"},
+		{"//td", "cell", "Apple"},
+		{"//select", "listbox", ""},
+		{"//a[@title]", "link", "More info"},
+		{"//header[@id='ah']", "", ""},
+		{"//body/footer", "contentinfo", ""},
+	} {
+		n := doc.FindOne(c.expr)
+		if n == nil {
+			t.Fatal(c.expr)
+		}
+		if role, name := n.Role(), n.AccessibleName(); role != c.role || name != c.name {
+			t.Errorf("%s: %q %q", c.expr, role, name)
+		}
+	}
+}
@@ -16,3 +16,99 @@ func (attr *Attribute) GetValue() string {
 func (attr *Attribute) GetNamespace() string {
 	return attr.Namespace
 }
+
+// SetValue sets the value of the attribute, the attribute of the document is changed. eg: n.Attribute("href").SetValue("/")
+func (attr *Attribute) SetValue(val string) {
+	attr.Val = val
+}
+
+// AttributeNode the node type of the attribute result. eg: //a/@href
+// the attribute node is not the child of its owner element, but its Parent is the owner element.
+// Text() returns the attribute value.
+const AttributeNode html.NodeType = 100
+
+// newAttributeNode create the attribute result node of owner.Attr[i]
+func newAttributeNode(owner *html.Node, i int) *Node {
+	attr := owner.Attr[i]
+	an := &html.Node{
+		Type:      AttributeNode,
+		Data:      attr.Key,
+		Namespace: attr.Namespace,
+		Parent:    owner,
+	}
+	value := &html.Node{
+		Type:   html.TextNode,
+		Data:   attr.Val,
+		Parent: an,
+	}
+	an.FirstChild = value
+	an.LastChild = value
+	return (*Node)(an)
+}
+
+// IsAttribute the node is the attribute result. eg: //a/@href
+func (n *Node) IsAttribute() bool {
+	return n.Type == AttributeNode
+}
+
+// IsText the node is the text node. eg: //p/text()
+func (n *Node) IsText() bool {
+	return n.Type == html.TextNode
+}
+
+// IsElement the node is the element node
+func (n *Node) IsElement() bool {
+	return n.Type == html.ElementNode
+}
+
+// OwnerElement the owner element of the attribute or the parent element of the text(comment) node.
+// nil if the node is element or has no parent element
+func (n *Node) OwnerElement() *Node {
+	if n.Type == html.ElementNode || n.Parent == nil || n.Parent.Type != html.ElementNode {
+		return nil
+	}
+	return (*Node)(n.Parent)
+}
+
+// Position the index of the attribute in the attributes of its owner, or the index of the node in the child nodes of its parent.
+// -1 if the node has no parent
+func (n *Node) Position() int {
+	if n.Parent == nil {
+		return -1
+	}
+	if n.Type == AttributeNode {
+		for i, attr := range n.Parent.Attr {
+			if attr.Key == n.Data && attr.Namespace == n.Namespace {
+				return i
+			}
+		}
+		return -1
+	}
+	i := 0
+	for c := n.Parent.FirstChild; c != nil && c != (*html.Node)(n); c = c.NextSibling {
+		i++
+	}
+	return i
+}
+
+// AsAttribute the Attribute of the attribute result in its owner element. nil if the node is not attribute result
+func (n *Node) AsAttribute() *Attribute {
+	if i := n.Position(); n.Type == AttributeNode && i != -1 {
+		return (*Attribute)(&n.Parent.Attr[i])
+	}
+	return nil
+}
+
+// Identity the comparable identity of the node. the attribute results of the same attribute
+// selected by different queries have the same identity
+func (n *Node) Identity() interface{} {
+	if n.Type == AttributeNode && n.Parent != nil {
+		return attributeIdentity{owner: n.Parent, key: n.Data, namespace: n.Namespace}
+	}
+	return n
+}
+
+type attributeIdentity struct {
+	owner          *html.Node
+	key, namespace string
+}
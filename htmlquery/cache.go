@@ -21,22 +21,33 @@ var (
 )
 
 func getQuery(expr string) (*xpath.Expr, error) {
-	if DisableSelectorCache || SelectorCacheMaxEntries <= 0 {
+	v, err := getCache(expr, func() (interface{}, error) {
 		return xpath.Compile(expr)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*xpath.Expr), nil
+}
+
+// getCache get the compiled value of the key. if not exists, compile it and add to the cache.
+func getCache(key lru.Key, compile func() (interface{}, error)) (interface{}, error) {
+	if DisableSelectorCache || SelectorCacheMaxEntries <= 0 {
+		return compile()
 	}
 	cacheOnce.Do(func() {
 		cache = lru.New(SelectorCacheMaxEntries)
 	})
 	cacheMutex.Lock()
 	defer cacheMutex.Unlock()
-	if v, ok := cache.Get(expr); ok {
-		return v.(*xpath.Expr), nil
+	if v, ok := cache.Get(key); ok {
+		return v, nil
 	}
-	v, err := xpath.Compile(expr)
+	v, err := compile()
 	if err != nil {
 		return nil, err
 	}
-	cache.Add(expr, v)
+	cache.Add(key, v)
 	return v, nil
 
 }
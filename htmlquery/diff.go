@@ -0,0 +1,389 @@
+package htmlquery
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ChangeType the type of the tree change
+type ChangeType string
+
+const (
+	// Inserted the node is only in the new tree
+	Inserted ChangeType = "inserted"
+	// Deleted the node is only in the old tree
+	Deleted ChangeType = "deleted"
+	// Moved the same subtree is at the different position
+	Moved ChangeType = "moved"
+	// TextChanged the text of text(comment) node is changed
+	TextChanged ChangeType = "text-changed"
+	// AttrChanged the attribute is added, removed or changed. the value of the missing side is empty
+	AttrChanged ChangeType = "attr-changed"
+)
+
+// Change the change between the old and new tree. OldXPath is empty for Inserted, NewXPath is empty for Deleted
+type Change struct {
+	Type     ChangeType `json:"type"`
+	OldXPath string     `json:"old_xpath,omitempty"`
+	NewXPath string     `json:"new_xpath,omitempty"`
+	Key      string     `json:"key,omitempty"`       // the attribute key of AttrChanged
+	OldValue string     `json:"old_value,omitempty"` // the old text or attribute value
+	NewValue string     `json:"new_value,omitempty"` // the new text or attribute value
+
+	Old *Node `json:"-"`
+	New *Node `json:"-"`
+}
+
+// Diff aligns the old and new tree and returns the changes. the subtree with the same content or id is moved.
+// whitespace only text is ignored, the text is compared after whitespace normalized
+func Diff(old, new *Node) []*Change {
+	return DiffNodes([]*Node{old}, []*Node{new})
+}
+
+// DiffNodes aligns the lists of old and new subtrees, and returns the changes of them.
+// eg: the results of the same xpath in two documents
+func DiffNodes(olds, news []*Node) []*Change {
+	d := &differ{hashes: make(map[*html.Node]uint64)}
+	var as, bs []*html.Node
+	for _, n := range olds {
+		as = append(as, (*html.Node)(n))
+	}
+	for _, n := range news {
+		bs = append(bs, (*html.Node)(n))
+	}
+	d.align(as, bs)
+	return d.moved()
+}
+
+// DiffXPath diffs only the subtrees selected by expr in the old and new tree
+func DiffXPath(old, new *Node, expr string) ([]*Change, error) {
+	olds, err := old.QueryAll(expr)
+	if err != nil {
+		return nil, err
+	}
+	news, err := new.QueryAll(expr)
+	if err != nil {
+		return nil, err
+	}
+	return DiffNodes(olds, news), nil
+}
+
+type differ struct {
+	hashes  map[*html.Node]uint64
+	changes []*Change
+}
+
+// hash 子树内容的hash, 属性排序, 文本规范化空白
+func (d *differ) hash(n *html.Node) uint64 {
+	if h, ok := d.hashes[n]; ok {
+		return h
+	}
+	h := fnv.New64a()
+	switch n.Type {
+	case html.TextNode, html.CommentNode:
+		h.Write([]byte(strconv.Itoa(int(n.Type)) + ":" + NormalizeSpace(n.Data)))
+	case AttributeNode:
+		h.Write([]byte(strconv.Itoa(int(n.Type)) + ":" + n.Data + "=" + attrNodeValue(n)))
+	default:
+		h.Write([]byte(strconv.Itoa(int(n.Type)) + ":" + n.Data))
+		attrs := make([]string, 0, len(n.Attr))
+		for _, attr := range n.Attr {
+			attrs = append(attrs, attr.Key+"="+attr.Val)
+		}
+		sort.Strings(attrs)
+		h.Write([]byte("[" + strings.Join(attrs, "\x00") + "]"))
+		for _, c := range diffChildren(n) {
+			h.Write([]byte(strconv.FormatUint(d.hash(c), 16) + ","))
+		}
+	}
+	d.hashes[n] = h.Sum64()
+	return d.hashes[n]
+}
+
+func (d *differ) diffNode(a, b *html.Node) {
+	if d.hash(a) == d.hash(b) {
+		return
+	}
+	switch a.Type {
+	case html.TextNode, html.CommentNode:
+		d.changes = append(d.changes, &Change{
+			Type: TextChanged, OldXPath: (*Node)(a).XPath(), NewXPath: (*Node)(b).XPath(),
+			OldValue: NormalizeSpace(a.Data), NewValue: NormalizeSpace(b.Data), Old: (*Node)(a), New: (*Node)(b),
+		})
+		return
+	case AttributeNode:
+		// 属性结果(eg: //a/@href) 报告为所属元素的属性变化
+		oa, ob := attrOwner(a), attrOwner(b)
+		d.changes = append(d.changes, &Change{
+			Type: AttrChanged, OldXPath: (*Node)(oa).XPath(), NewXPath: (*Node)(ob).XPath(),
+			Key: a.Data, OldValue: attrNodeValue(a), NewValue: attrNodeValue(b), Old: (*Node)(oa), New: (*Node)(ob),
+		})
+		return
+	case html.ElementNode:
+		d.diffAttrs(a, b)
+	}
+	d.align(diffChildren(a), diffChildren(b))
+}
+
+// attrOwner 属性结果所属的元素, 没有所属元素返回自己
+func attrOwner(n *html.Node) *html.Node {
+	if n.Parent != nil {
+		return n.Parent
+	}
+	return n
+}
+
+func attrNodeValue(n *html.Node) string {
+	if n.FirstChild != nil {
+		return n.FirstChild.Data
+	}
+	return ""
+}
+
+func (d *differ) diffAttrs(a, b *html.Node) {
+	change := func(key, ov, nv string) {
+		d.changes = append(d.changes, &Change{
+			Type: AttrChanged, OldXPath: (*Node)(a).XPath(), NewXPath: (*Node)(b).XPath(),
+			Key: key, OldValue: ov, NewValue: nv, Old: (*Node)(a), New: (*Node)(b),
+		})
+	}
+	for _, attr := range a.Attr {
+		if v := (*Node)(b).getAttr(attr.Key); v != attr.Val || !(*Node)(b).hasAttr(attr.Key) {
+			change(attr.Key, attr.Val, v)
+		}
+	}
+	for _, attr := range b.Attr {
+		if !(*Node)(a).hasAttr(attr.Key) {
+			change(attr.Key, "", attr.Val)
+		}
+	}
+}
+
+// align 先按子树hash对齐相同的节点, 剩下的在间隔里按标签对齐后递归比较
+func (d *differ) align(as, bs []*html.Node) {
+	same := lcsPairs(d.hashKeys(as), d.hashKeys(bs))
+	ai, bi := 0, 0
+	for _, p := range append(same, [2]int{len(as), len(bs)}) {
+		ga, gb := as[ai:p[0]], bs[bi:p[1]]
+		x, y := 0, 0
+		for _, q := range lcsPairs(labelKeys(ga), labelKeys(gb)) {
+			for ; x < q[0]; x++ {
+				d.deleted(ga[x])
+			}
+			for ; y < q[1]; y++ {
+				d.inserted(gb[y])
+			}
+			d.diffNode(ga[x], gb[y])
+			x, y = x+1, y+1
+		}
+		for ; x < len(ga); x++ {
+			d.deleted(ga[x])
+		}
+		for ; y < len(gb); y++ {
+			d.inserted(gb[y])
+		}
+		ai, bi = p[0]+1, p[1]+1
+	}
+}
+
+func (d *differ) hashKeys(nodes []*html.Node) []uint64 {
+	keys := make([]uint64, len(nodes))
+	for i, n := range nodes {
+		keys[i] = d.hash(n)
+	}
+	return keys
+}
+
+func labelKeys(nodes []*html.Node) []uint64 {
+	keys := make([]uint64, len(nodes))
+	for i, n := range nodes {
+		h := fnv.New64a()
+		h.Write([]byte(diffLabel(n)))
+		keys[i] = h.Sum64()
+	}
+	return keys
+}
+
+func (d *differ) deleted(n *html.Node) {
+	d.changes = append(d.changes, &Change{Type: Deleted, OldXPath: (*Node)(n).XPath(), Old: (*Node)(n)})
+}
+
+func (d *differ) inserted(n *html.Node) {
+	d.changes = append(d.changes, &Change{Type: Inserted, NewXPath: (*Node)(n).XPath(), New: (*Node)(n)})
+}
+
+// moved 相同内容或者相同id的删除和插入合并为移动, 相同id的再比较内部的变化
+func (d *differ) moved() []*Change {
+	merged := make(map[*Change]bool)
+	for changed := true; changed; {
+		changed = false
+		inserted := make(map[string][]*Change)
+		for _, c := range d.changes {
+			if c.Type == Inserted && !merged[c] {
+				n := (*html.Node)(c.New)
+				key := strconv.FormatUint(d.hash(n), 16)
+				inserted[key] = append(inserted[key], c)
+				if id := (*Node)(n).GetAttributeByKey("id"); id != nil && n.Type == html.ElementNode {
+					inserted["#"+id.Val] = append(inserted["#"+id.Val], c)
+				}
+			}
+		}
+
+		take := func(key string) *Change {
+			for _, c := range inserted[key] {
+				if !merged[c] {
+					return c
+				}
+			}
+			return nil
+		}
+		for _, c := range d.changes {
+			if c.Type != Deleted {
+				continue
+			}
+			n := (*html.Node)(c.Old)
+			if ins := take(strconv.FormatUint(d.hash(n), 16)); ins != nil {
+				c.Type, c.NewXPath, c.New = Moved, ins.NewXPath, ins.New
+				merged[ins] = true
+			} else if id := (*Node)(n).GetAttributeByKey("id"); id != nil && n.Type == html.ElementNode {
+				if ins := take("#" + id.Val); ins != nil {
+					c.Type, c.NewXPath, c.New = Moved, ins.NewXPath, ins.New
+					merged[ins] = true
+					d.diffNode(n, (*html.Node)(ins.New))
+					changed = true
+				}
+			}
+		}
+	}
+
+	var changes []*Change
+	for _, c := range d.changes {
+		if !merged[c] {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// diffChildren 忽略空白文本和注释以外的子节点
+func diffChildren(n *html.Node) []*html.Node {
+	var children []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode && strings.TrimSpace(c.Data) == "" {
+			continue
+		}
+		children = append(children, c)
+	}
+	return children
+}
+
+// diffLabel 可以对齐比较的节点有相同的标签. eg: div div#main #text
+func diffLabel(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return "#text"
+	case html.CommentNode:
+		return "#comment"
+	case AttributeNode:
+		return "@" + n.Data
+	case html.ElementNode:
+		if id := (*Node)(n).GetAttributeByKey("id"); id != nil {
+			return n.Data + "#" + id.Val
+		}
+		return n.Data
+	}
+	return "#" + strconv.Itoa(int(n.Type))
+}
+
+// maxLCSCells lcs表的最大格数, 超过的用线性的贪心对齐
+var maxLCSCells = 1 << 20
+
+// lcsPairs the index pairs of the longest common subsequence of the keys. the common prefix and suffix are trimmed first,
+// the large inputs(more than maxLCSCells) are aligned greedily in linear time
+func lcsPairs(as, bs []uint64) [][2]int {
+	var pairs [][2]int
+	start := 0
+	for start < len(as) && start < len(bs) && as[start] == bs[start] {
+		pairs = append(pairs, [2]int{start, start})
+		start++
+	}
+	ea, eb := len(as), len(bs)
+	for ea > start && eb > start && as[ea-1] == bs[eb-1] {
+		ea, eb = ea-1, eb-1
+	}
+
+	var middle [][2]int
+	if n, m := ea-start, eb-start; n > 0 && m > 0 {
+		if n*m > maxLCSCells {
+			middle = greedyPairs(as[start:ea], bs[start:eb])
+		} else {
+			middle = lcsTable(as[start:ea], bs[start:eb])
+		}
+	}
+	for _, p := range middle {
+		pairs = append(pairs, [2]int{p[0] + start, p[1] + start})
+	}
+	for i := 0; ea+i < len(as); i++ {
+		pairs = append(pairs, [2]int{ea + i, eb + i})
+	}
+	return pairs
+}
+
+// lcsTable 动态规划, 一次分配 (n+1)*(m+1) 的表
+func lcsTable(as, bs []uint64) [][2]int {
+	n, m := len(as), len(bs)
+	w := m + 1
+	dp := make([]int32, (n+1)*w)
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if as[i] == bs[j] {
+				dp[i*w+j] = dp[(i+1)*w+j+1] + 1
+			} else if dp[(i+1)*w+j] >= dp[i*w+j+1] {
+				dp[i*w+j] = dp[(i+1)*w+j]
+			} else {
+				dp[i*w+j] = dp[i*w+j+1]
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case as[i] == bs[j]:
+			pairs = append(pairs, [2]int{i, j})
+			i, j = i+1, j+1
+		case dp[(i+1)*w+j] >= dp[i*w+j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// greedyPairs 每个 as[i] 对齐到上一个对齐位置之后第一个相同的 bs[j]. 不一定最长, 但是线性的
+func greedyPairs(as, bs []uint64) [][2]int {
+	positions := make(map[uint64][]int)
+	for j, k := range bs {
+		positions[k] = append(positions[k], j)
+	}
+	var pairs [][2]int
+	last := -1
+	for i, k := range as {
+		ps := positions[k]
+		for len(ps) > 0 && ps[0] <= last {
+			ps = ps[1:]
+		}
+		if len(ps) > 0 {
+			last = ps[0]
+			pairs = append(pairs, [2]int{i, last})
+			ps = ps[1:]
+		}
+		positions[k] = ps
+	}
+	return pairs
+}
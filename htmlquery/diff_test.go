@@ -0,0 +1,132 @@
+package htmlquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := loadHTML(`<html><body>
+		<h1>Price list</h1>
+		<ul>
+			<li id="a">apple <b>10</b></li>
+			<li id="b">banana <b>20</b></li>
+			<li id="c">cherry <b>30</b></li>
+		</ul>
+		<!-- comment -->
+		<p class="note" title="x">old note</p>
+		<div id="ad">ad</div>
+	</body></html>`)
+	new := loadHTML(`<html><body>
+		<h1>Price list</h1>
+		<ul>
+			<li id="b">banana <b>20</b></li>
+			<li id="a">apple <b>12</b></li>
+			<li id="c">cherry <b>30</b></li>
+			<li id="d">durian <b>50</b></li>
+		</ul>
+		<p class="note hot">new note</p>
+	</body></html>`)
+
+	var got []string
+	for _, c := range Diff(old, new) {
+		got = append(got, fmt.Sprintf("%s %s %s %s %s->%s", c.Type, c.OldXPath, c.NewXPath, c.Key, c.OldValue, c.NewValue))
+	}
+	want := []string{
+		"moved /html[1]/body[1]/ul[1]/li[1] /html[1]/body[1]/ul[1]/li[2]  ->",
+		"inserted  /html[1]/body[1]/ul[1]/li[4]  ->",
+		"deleted /html[1]/body[1]/comment()[1]   ->",
+		"attr-changed /html[1]/body[1]/p[1] /html[1]/body[1]/p[1] class note->note hot",
+		"attr-changed /html[1]/body[1]/p[1] /html[1]/body[1]/p[1] title x->",
+		"text-changed /html[1]/body[1]/p[1]/text()[1] /html[1]/body[1]/p[1]/text()[1]  old note->new note",
+		"deleted /html[1]/body[1]/div[1]   ->",
+		"text-changed /html[1]/body[1]/ul[1]/li[1]/b[1]/text()[1] /html[1]/body[1]/ul[1]/li[2]/b[1]/text()[1]  10->12",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Error("\n" + strings.Join(got, "\n"))
+	}
+
+	if changes := Diff(old, loadHTML(old.OutputHTML(true))); len(changes) != 0 {
+		t.Error(changes)
+	}
+
+	data, err := json.Marshal(Diff(old.FindOne("//h1"), new.FindOne("//p")))
+	if err != nil || string(data) != `[{"type":"deleted","old_xpath":"/html[1]/body[1]/h1[1]"},{"type":"inserted","new_xpath":"/html[1]/body[1]/p[1]"}]` {
+		t.Error(string(data), err)
+	}
+}
+
+func TestDiffXPath(t *testing.T) {
+	old := loadHTML(`<div><span class="price">10</span><span class="stock">5</span></div>`)
+	new := loadHTML(`<div><span class="price">12</span><span class="stock">4</span></div>`)
+	changes, err := DiffXPath(old, new, "//span[@class='price']")
+	if err != nil || len(changes) != 1 || changes[0].OldValue != "10" || changes[0].NewValue != "12" {
+		t.Error(changes, err)
+	}
+	if _, err := DiffXPath(old, new, "//span["); err == nil {
+		t.Error("should be error")
+	}
+}
+
+func TestDiffAttributeResults(t *testing.T) {
+	old := loadHTML(`<div><a href="/a">a</a><a href="/b" title="b">b</a></div>`)
+	new := loadHTML(`<div><a href="/a2">a</a><a href="/b">b</a></div>`)
+
+	var got []string
+	for _, c := range mustDiffXPath(t, old, new, "//a/@href") {
+		got = append(got, fmt.Sprintf("%s %s %s %s %s->%s", c.Type, c.OldXPath, c.NewXPath, c.Key, c.OldValue, c.NewValue))
+	}
+	if want := "attr-changed /html[1]/body[1]/div[1]/a[1] /html[1]/body[1]/div[1]/a[1] href /a->/a2"; strings.Join(got, "\n") != want {
+		t.Error("\n" + strings.Join(got, "\n"))
+	}
+
+	got = got[:0]
+	for _, c := range mustDiffXPath(t, old, new, "//a[2]/@*") {
+		got = append(got, fmt.Sprintf("%s %s %s", c.Type, c.OldXPath, c.NewXPath))
+	}
+	if want := "deleted /html[1]/body[1]/div[1]/a[2]/@title "; strings.Join(got, "\n") != want {
+		t.Error("\n" + strings.Join(got, "\n"))
+	}
+}
+
+func mustDiffXPath(t *testing.T, old, new *Node, expr string) []*Change {
+	changes, err := DiffXPath(old, new, expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return changes
+}
+
+func TestLCSPairs(t *testing.T) {
+	keys := func(s string) []uint64 {
+		var ks []uint64
+		for _, c := range s {
+			ks = append(ks, uint64(c))
+		}
+		return ks
+	}
+	if got := fmt.Sprint(lcsPairs(keys("abxcd"), keys("abycd"))); got != "[[0 0] [1 1] [3 3] [4 4]]" {
+		t.Error(got)
+	}
+	if got := fmt.Sprint(lcsPairs(keys("xaby"), keys("abz"))); got != "[[1 0] [2 1]]" {
+		t.Error(got)
+	}
+
+	// 大的列表不会分配 n*m 的表
+	var as, bs []uint64
+	for i := 0; i < 20000; i++ {
+		as = append(as, uint64(i))
+		bs = append(bs, uint64(20000-i))
+	}
+	if pairs := lcsPairs(as, bs); len(pairs) != 1 {
+		t.Error(len(pairs))
+	}
+
+	defer func(cells int) { maxLCSCells = cells }(maxLCSCells)
+	maxLCSCells = 4
+	if got := fmt.Sprint(lcsPairs(keys("qxaybz"), keys("wabcz"))); got != "[[2 1] [4 2] [5 4]]" {
+		t.Error(got)
+	}
+}
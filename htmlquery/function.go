@@ -0,0 +1,707 @@
+package htmlquery
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+	"golang.org/x/text/unicode/norm"
+)
+
+// FuncType the result type of the extended xpath function
+type FuncType int
+
+const (
+	// FuncString the function returns string
+	FuncString FuncType = iota
+	// FuncNumber the function returns float64
+	FuncNumber
+	// FuncBool the function returns bool
+	FuncBool
+)
+
+// FunctionCall is the go function called by xpath expression.
+// ctx is the context node of the call. args are the xpath values of the arguments,
+// one of string, float64, bool or []*Node. see StringValue NumberValue BoolValue
+type FunctionCall func(ctx *Node, args ...interface{}) interface{}
+
+// Function the extended xpath function
+type Function struct {
+	Type FuncType
+	Call FunctionCall
+
+	// prepare 编译时检查参数(参数的表达式), 可以返回这个调用位置专用的 Call. nil Call 使用 Function.Call
+	prepare func(args []string) (FunctionCall, error)
+}
+
+// funcPrefix 扩展函数的前缀 ext:matches() == matches()
+const funcPrefix = "ext:"
+
+// Functions the set of the extended xpath functions. the functions can be called in xpath expression
+// eg: //div[has-class('btn')]  //a[ext:matches(@href, '\d+')]  lower-case(string(//title))
+//
+// The call is evaluated with the context node of the expression: element, document, text, comment or attribute.
+// position() and last() are not available in the arguments. the virtual attributes of the calls are not in @* .
+type Functions struct {
+	mu      sync.RWMutex
+	funcs   map[string]*Function
+	version int
+}
+
+var builtinFunctions = map[string]*Function{
+	"lower-case":        {Type: FuncString, Call: lowerCaseFunc, prepare: maxArgs("lower-case", 1)},
+	"upper-case":        {Type: FuncString, Call: upperCaseFunc, prepare: maxArgs("upper-case", 1)},
+	"matches":           {Type: FuncBool, Call: matchesFunc, prepare: prepareMatches},
+	"has-class":         {Type: FuncBool, Call: hasClassFunc},
+	"normalize-unicode": {Type: FuncString, Call: normalizeUnicodeFunc, prepare: prepareNormalizeUnicode},
+}
+
+// defaultFunctions 只有内置函数. Node.QueryAll Node.Evaluate 使用
+var defaultFunctions = NewFunctions()
+
+// NewFunctions create the functions set with the builtin functions
+// lower-case() upper-case() matches() has-class() normalize-unicode()
+func NewFunctions() *Functions {
+	fs := &Functions{funcs: make(map[string]*Function)}
+	for name, f := range builtinFunctions {
+		fs.funcs[name] = f
+	}
+	return fs
+}
+
+// Register register the go function to xpath. the name can be called with or without ext: prefix.
+// the registered function has higher priority than the xpath builtin function with the same name.
+// the panic of the call is returned as the error of the query.
+func (fs *Functions) Register(name string, typ FuncType, call FunctionCall) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.funcs[strings.TrimPrefix(name, funcPrefix)] = &Function{Type: typ, Call: call}
+	fs.version++ // 使已缓存的表达式失效
+}
+
+// Lookup get the function by name
+func (fs *Functions) Lookup(name string) (*Function, bool) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	f, ok := fs.funcs[strings.TrimPrefix(name, funcPrefix)]
+	return f, ok
+}
+
+// QueryAll is like Node.QueryAll, but the expression can call the functions of fs.
+// nil fs is the builtin functions.
+func (fs *Functions) QueryAll(n *Node, expr string) ([]*Node, error) {
+	return fs.QueryAllWithVars(n, expr, nil)
+}
+
+// QueryAllWithVars is like QueryAll, and binds the variables of the expression. see Node.QueryAllWithVars
+func (fs *Functions) QueryAllWithVars(n *Node, expr string, vars map[string]interface{}) ([]*Node, error) {
+	exp, err := fs.getQuery(expr, vars)
+	if err != nil {
+		return nil, err
+	}
+	return exp.selectAll(n, vars)
+}
+
+// Query is like Node.Query, but the expression can call the functions of fs.
+func (fs *Functions) Query(n *Node, expr string) (*Node, error) {
+	return fs.QueryWithVars(n, expr, nil)
+}
+
+// QueryWithVars is like Query, and binds the variables of the expression.
+func (fs *Functions) QueryWithVars(n *Node, expr string, vars map[string]interface{}) (*Node, error) {
+	exp, err := fs.getQuery(expr, vars)
+	if err != nil {
+		return nil, err
+	}
+	return exp.selectOne(n, vars)
+}
+
+// Evaluate is like Node.Evaluate, but the expression can call the functions of fs.
+func (fs *Functions) Evaluate(n *Node, expr string) (interface{}, error) {
+	return fs.EvaluateWithVars(n, expr, nil)
+}
+
+// EvaluateWithVars is like Evaluate, and binds the variables of the expression.
+func (fs *Functions) EvaluateWithVars(n *Node, expr string, vars map[string]interface{}) (interface{}, error) {
+	exp, err := fs.getQuery(expr, vars)
+	if err != nil {
+		return nil, err
+	}
+	return exp.evaluate(n, vars)
+}
+
+// getQuery 缓存与变量的值无关, 只与变量的名字和类型有关
+func (fs *Functions) getQuery(expr string, vars map[string]interface{}) (*compiledExpr, error) {
+	if fs == nil {
+		fs = defaultFunctions
+	}
+	fs.mu.RLock()
+	key := funcCacheKey{fs: fs, version: fs.version, expr: expr, vars: varsSignature(vars)}
+	fs.mu.RUnlock()
+	if key.version == 0 {
+		// 没有注册过函数的集合和defaultFunctions相同, 共用缓存. 每个页面的提取器都有自己的集合
+		key.fs = defaultFunctions
+	}
+
+	v, err := getCache(key, func() (interface{}, error) {
+		return fs.compile(expr, varTypes(vars))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*compiledExpr), nil
+}
+
+type funcCacheKey struct {
+	fs      *Functions
+	version int
+	expr    string
+	vars    string
+}
+
+// compiledExpr 编译后的表达式. 扩展函数的调用被改写为上下文节点的虚拟属性 @xf:f0 @xf:f1 ...
+// 变量被改写为根节点的虚拟属性 /@xv:name
+type compiledExpr struct {
+	expr  *xpath.Expr
+	calls []*funcCall
+	vars  []string // 表达式使用的变量名
+}
+
+type funcCall struct {
+	name string
+	fn   *Function
+	call FunctionCall
+	args []*compiledExpr
+}
+
+// regexpAnyAttr 属性轴的 * node()
+var regexpAnyAttr = regexp.MustCompile(`^(\*|node\s*\(\s*\))`)
+
+// 虚拟属性的前缀
+const (
+	funcAttrPrefix = "xf"
+	varAttrPrefix  = "xv"
+)
+
+// refVirtualAttr 按类型引用虚拟属性
+func refVirtualAttr(attr string, typ FuncType) string {
+	switch typ {
+	case FuncNumber:
+		return "number(" + attr + ")"
+	case FuncBool:
+		return "(" + attr + "='true')"
+	default:
+		return "string(" + attr + ")"
+	}
+}
+
+func (fs *Functions) compile(expr string, vtypes map[string]FuncType) (*compiledExpr, error) {
+	ce := &compiledExpr{}
+	var buf strings.Builder
+
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end == -1 {
+				return nil, fmt.Errorf("%s has unclosed string literal", expr)
+			}
+			buf.WriteString(expr[i : i+end+2])
+			i += end + 2
+		case c == '$':
+			name := scanQName(expr, i+1)
+			typ, ok := vtypes[name]
+			if !ok {
+				return nil, fmt.Errorf("xpath: variable $%s is not bound in %s", name, expr)
+			}
+			ce.addVar(name)
+			buf.WriteString(refVirtualAttr(fmt.Sprintf("/@%s:%s", varAttrPrefix, name), typ))
+			i += 1 + len(name)
+		case c == '@' || strings.HasPrefix(expr[i:], "attribute::"):
+			axis := "@"
+			if c != '@' {
+				axis = "attribute::"
+			}
+			j := skipSpace(expr, i+len(axis))
+			if test := regexpAnyAttr.FindString(expr[j:]); test != "" {
+				// 虚拟属性有前缀, 真实属性没有. @* 不包含虚拟属性
+				buf.WriteString(axis + test + "[name()=local-name()]")
+				i = j + len(test)
+				continue
+			}
+			name := scanQName(expr, j)
+			buf.WriteString(expr[i : j+len(name)])
+			i = j + len(name)
+		case isNameStart(c):
+			name := scanQName(expr, i)
+			next := skipSpace(expr, i+len(name))
+			f, ok := fs.Lookup(name)
+			if !ok || next >= len(expr) || expr[next] != '(' {
+				buf.WriteString(name)
+				i += len(name)
+				continue
+			}
+
+			args, end, err := splitArgs(expr, next)
+			if err != nil {
+				return nil, err
+			}
+			call := &funcCall{name: name, fn: f, call: f.Call}
+			if f.prepare != nil {
+				prepared, err := f.prepare(args)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %v", expr, err)
+				}
+				if prepared != nil {
+					call.call = prepared
+				}
+			}
+			for _, arg := range args {
+				ace, err := fs.compile(arg, vtypes)
+				if err != nil {
+					return nil, err
+				}
+				for _, name := range ace.vars {
+					ce.addVar(name)
+				}
+				call.args = append(call.args, ace)
+			}
+
+			attr := fmt.Sprintf("@%s:f%d", funcAttrPrefix, len(ce.calls))
+			ce.calls = append(ce.calls, call)
+			buf.WriteString(refVirtualAttr(attr, f.Type))
+			i = end + 1
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+
+	exp, err := xpath.Compile(buf.String())
+	if err != nil {
+		return nil, err
+	}
+	ce.expr = exp
+	return ce, nil
+}
+
+func (ce *compiledExpr) addVar(name string) {
+	for _, v := range ce.vars {
+		if v == name {
+			return
+		}
+	}
+	ce.vars = append(ce.vars, name)
+}
+
+func (ce *compiledExpr) navigator(n *Node, vars map[string]interface{}) *NodeNavigator {
+	nav := n.CreateXPathNavigator()
+	nav.calls = ce.calls
+	nav.vars = bindVars(ce.vars, vars)
+	return nav
+}
+
+func (ce *compiledExpr) selectAll(n *Node, vars map[string]interface{}) (nodes []*Node, err error) {
+	defer recoverCall(&err)
+	return selectNodes(ce.expr.Select(ce.navigator(n, vars))), nil
+}
+
+func (ce *compiledExpr) selectOne(n *Node, vars map[string]interface{}) (node *Node, err error) {
+	defer recoverCall(&err)
+	t := ce.expr.Select(ce.navigator(n, vars))
+	for t.MoveNext() {
+		nav := t.Current().(*NodeNavigator)
+		if !nav.isVirtualAttr() {
+			return getCurrentNode(nav), nil
+		}
+	}
+	return nil, nil
+}
+
+func (ce *compiledExpr) evaluate(n *Node, vars map[string]interface{}) (v interface{}, err error) {
+	defer recoverCall(&err)
+	return evaluateNavigator(ce.expr, ce.navigator(n, vars)), nil
+}
+
+// callError 扩展函数调用的panic, 查询的时候转为error返回
+type callError struct {
+	err error
+}
+
+func recoverCall(err *error) {
+	if r := recover(); r != nil {
+		ce, ok := r.(*callError)
+		if !ok {
+			panic(r)
+		}
+		*err = ce.err
+	}
+}
+
+func evaluateNavigator(expr *xpath.Expr, nav *NodeNavigator) interface{} {
+	switch v := expr.Evaluate(nav).(type) {
+	case *xpath.NodeIterator:
+		return selectNodes(v)
+	default:
+		return v
+	}
+}
+
+// value 计算虚拟属性的值. 参数以调用所在的上下文节点(属性的上下文是属性本身)计算
+func (call *funcCall) value(h *NodeNavigator) string {
+	ctx := (*Node)(h.curr)
+	if h.ctxAttr != -1 {
+		ctx = newAttributeNode(h.curr, h.ctxAttr)
+	}
+	var args []interface{}
+	for _, arg := range call.args {
+		nav := &NodeNavigator{root: h.root, curr: h.curr, attr: h.ctxAttr, ctxAttr: -1, calls: arg.calls, vars: h.vars}
+		args = append(args, evaluateNavigator(arg.expr, nav))
+	}
+
+	result := call.invoke(ctx, args)
+	switch call.fn.Type {
+	case FuncBool:
+		return strconv.FormatBool(BoolValue(result))
+	case FuncNumber:
+		return StringValue(NumberValue(result))
+	default:
+		return StringValue(result)
+	}
+}
+
+func (call *funcCall) invoke(ctx *Node, args []interface{}) (result interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*callError); ok {
+				panic(r)
+			}
+			panic(&callError{fmt.Errorf("xpath: %s(): %v", call.name, r)})
+		}
+	}()
+	return call.call(ctx, args...)
+}
+
+// StringValue converts the xpath value(string float64 bool []*Node) to string like xpath string()
+func StringValue(v interface{}) string {
+	switch rv := v.(type) {
+	case string:
+		return rv
+	case bool:
+		return strconv.FormatBool(rv)
+	case float64:
+		if math.IsNaN(rv) {
+			return "NaN"
+		}
+		return strconv.FormatFloat(rv, 'f', -1, 64)
+	case []*Node:
+		if len(rv) > 0 {
+			return nodeValue(rv[0])
+		}
+		return ""
+	case *Node:
+		return nodeValue(rv)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(rv)
+	}
+}
+
+// nodeValue 节点的字符串值, 注释是注释的内容
+func nodeValue(n *Node) string {
+	if n.Type == html.CommentNode {
+		return n.Data
+	}
+	return n.InnerText()
+}
+
+// NumberValue converts the xpath value(string float64 bool []*Node) to float64 like xpath number()
+func NumberValue(v interface{}) float64 {
+	switch rv := v.(type) {
+	case float64:
+		return rv
+	case int:
+		return float64(rv)
+	case bool:
+		if rv {
+			return 1
+		}
+		return 0
+	default:
+		f, err := strconv.ParseFloat(strings.TrimSpace(StringValue(rv)), 64)
+		if err != nil {
+			return math.NaN()
+		}
+		return f
+	}
+}
+
+// BoolValue converts the xpath value(string float64 bool []*Node) to bool like xpath boolean()
+func BoolValue(v interface{}) bool {
+	switch rv := v.(type) {
+	case bool:
+		return rv
+	case float64:
+		return rv != 0 && !math.IsNaN(rv)
+	case string:
+		return rv != ""
+	case []*Node:
+		return len(rv) > 0
+	default:
+		return v != nil
+	}
+}
+
+func lowerCaseFunc(ctx *Node, args ...interface{}) interface{} {
+	return strings.ToLower(stringArg(ctx, args, 0))
+}
+
+func upperCaseFunc(ctx *Node, args ...interface{}) interface{} {
+	return strings.ToUpper(stringArg(ctx, args, 0))
+}
+
+// matches(input, pattern [, flags]) flags: i s m
+func matchesFunc(ctx *Node, args ...interface{}) interface{} {
+	if len(args) < 2 {
+		panic("matches function must have two or three parameters")
+	}
+	re, err := compileMatches(StringValue(args[1]), flagsArg(args))
+	if err != nil {
+		panic(err)
+	}
+	return re.MatchString(StringValue(args[0]))
+}
+
+func flagsArg(args []interface{}) string {
+	if len(args) > 2 {
+		return StringValue(args[2])
+	}
+	return ""
+}
+
+func compileMatches(pattern, flags string) (*regexp.Regexp, error) {
+	if strings.Trim(flags, "ism") != "" {
+		return nil, fmt.Errorf("matches flags %s is not supported, only i s m", flags)
+	}
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// prepareMatches 字面量的模式在编译时编译一次, 其他的模式每个调用位置缓存上一次的结果
+func prepareMatches(args []string) (FunctionCall, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, fmt.Errorf("matches function must have two or three parameters")
+	}
+	pattern, pok := literalArg(args[1])
+	flags, fok := "", true
+	if len(args) > 2 {
+		flags, fok = literalArg(args[2])
+	}
+	if pok && fok {
+		re, err := compileMatches(pattern, flags)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx *Node, args ...interface{}) interface{} {
+			return re.MatchString(StringValue(args[0]))
+		}, nil
+	}
+
+	var mu sync.Mutex
+	var last string
+	var lastRe *regexp.Regexp
+	return func(ctx *Node, args ...interface{}) interface{} {
+		key := flagsArg(args) + "/" + StringValue(args[1])
+		mu.Lock()
+		re := lastRe
+		if re == nil || key != last {
+			var err error
+			if re, err = compileMatches(StringValue(args[1]), flagsArg(args)); err != nil {
+				mu.Unlock()
+				panic(err)
+			}
+			last, lastRe = key, re
+		}
+		mu.Unlock()
+		return re.MatchString(StringValue(args[0]))
+	}, nil
+}
+
+// has-class(name...) 上下文节点的class包含所有的name
+func hasClassFunc(ctx *Node, args ...interface{}) interface{} {
+	if ctx.Type != html.ElementNode {
+		return false
+	}
+	classes := strings.Fields(ctx.getAttr("class"))
+	for _, arg := range args {
+		var found bool
+		for _, class := range classes {
+			if class == StringValue(arg) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return len(args) > 0
+}
+
+// normalize-unicode(input [, form]) form: NFC(default) NFD NFKC NFKD
+func normalizeUnicodeFunc(ctx *Node, args ...interface{}) interface{} {
+	form := norm.NFC
+	if len(args) > 1 {
+		var err error
+		if form, err = unicodeForm(StringValue(args[1])); err != nil {
+			panic(err)
+		}
+	}
+	return form.String(stringArg(ctx, args, 0))
+}
+
+func unicodeForm(name string) (norm.Form, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "NFD":
+		return norm.NFD, nil
+	case "NFKC":
+		return norm.NFKC, nil
+	case "NFKD":
+		return norm.NFKD, nil
+	case "NFC", "":
+		return norm.NFC, nil
+	}
+	return norm.NFC, fmt.Errorf("normalize-unicode form %s is not exists", name)
+}
+
+// prepareNormalizeUnicode 字面量的form在编译时检查
+func prepareNormalizeUnicode(args []string) (FunctionCall, error) {
+	if len(args) > 2 {
+		return nil, fmt.Errorf("normalize-unicode function must have at most two parameters")
+	}
+	if len(args) < 2 {
+		return nil, nil
+	}
+	name, ok := literalArg(args[1])
+	if !ok {
+		return nil, nil
+	}
+	form, err := unicodeForm(name)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx *Node, args ...interface{}) interface{} {
+		return form.String(stringArg(ctx, args, 0))
+	}, nil
+}
+
+// maxArgs 只检查参数的数量
+func maxArgs(name string, max int) func(args []string) (FunctionCall, error) {
+	return func(args []string) (FunctionCall, error) {
+		if len(args) > max {
+			return nil, fmt.Errorf("%s function must have at most %d parameters", name, max)
+		}
+		return nil, nil
+	}
+}
+
+// literalArg 参数是字符串字面量的时候返回它的值. eg: '\d+'
+func literalArg(arg string) (string, bool) {
+	if len(arg) < 2 || arg[0] != '"' && arg[0] != '\'' || arg[len(arg)-1] != arg[0] {
+		return "", false
+	}
+	if strings.IndexByte(arg[1:len(arg)-1], arg[0]) != -1 {
+		return "", false
+	}
+	return arg[1 : len(arg)-1], true
+}
+
+// stringArg 获取第i个参数, 没有参数的时候取上下文节点的值
+func stringArg(ctx *Node, args []interface{}, i int) string {
+	if i < len(args) {
+		return StringValue(args[i])
+	}
+	return nodeValue(ctx)
+}
+
+func (n *Node) getAttr(key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || c == '-' || c == '.' || c >= '0' && c <= '9'
+}
+
+// scanQName 读取 name 或者 prefix:name. axis:: 不会被读取
+func scanQName(expr string, i int) string {
+	j := i
+	for j < len(expr) && isNameChar(expr[j]) {
+		j++
+	}
+	if j+1 < len(expr) && expr[j] == ':' && isNameStart(expr[j+1]) {
+		j++
+		for j < len(expr) && isNameChar(expr[j]) {
+			j++
+		}
+	}
+	return expr[i:j]
+}
+
+func skipSpace(expr string, i int) int {
+	for i < len(expr) && (expr[i] == ' ' || expr[i] == '\t' || expr[i] == '\n' || expr[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// splitArgs 分割函数参数. start 是 '(' 的位置, end 是对应 ')' 的位置
+func splitArgs(expr string, start int) (args []string, end int, err error) {
+	depth := 0
+	last := start + 1
+	for i := start + 1; i < len(expr); i++ {
+		switch c := expr[i]; c {
+		case '"', '\'':
+			e := strings.IndexByte(expr[i+1:], c)
+			if e == -1 {
+				return nil, 0, fmt.Errorf("%s has unclosed string literal", expr)
+			}
+			i += e + 1
+		case '(', '[':
+			depth++
+		case ']':
+			depth--
+		case ')':
+			if depth == 0 {
+				if arg := strings.TrimSpace(expr[last:i]); arg != "" || len(args) > 0 {
+					args = append(args, arg)
+				}
+				return args, i, nil
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(expr[last:i]))
+				last = i + 1
+			}
+		}
+	}
+	return nil, 0, fmt.Errorf("%s has unclosed function call", expr)
+}
@@ -0,0 +1,204 @@
+package htmlquery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltinFunctions(t *testing.T) {
+	doc := loadHTML(`<html><head><title>Hello WORLD</title></head><body>
+		<a class="btn btn-primary" href="/item/123">Buy</a>
+		<a class="btn" href="/about">About</a>
+		<a class="link" href="/item/456">ＡＢＣ</a>
+	</body></html>`)
+
+	nodes, err := doc.QueryAll("//a[has-class('btn')]")
+	if err != nil || len(nodes) != 2 {
+		t.Fatal(nodes, err)
+	}
+
+	nodes, err = doc.QueryAll("//a[has-class('btn', 'btn-primary')]")
+	if err != nil || len(nodes) != 1 || nodes[0].InnerText() != "Buy" {
+		t.Fatal(nodes, err)
+	}
+
+	nodes, err = doc.QueryAll(`//a[ext:matches(@href, '\d+$')]`)
+	if err != nil || len(nodes) != 2 {
+		t.Fatal(nodes, err)
+	}
+
+	nodes, err = doc.QueryAll(`//a[matches(., 'about', 'i')]`)
+	if err != nil || len(nodes) != 1 || nodes[0].InnerText() != "About" {
+		t.Fatal(nodes, err)
+	}
+
+	if v, err := doc.Evaluate("lower-case(string(//title))"); err != nil || v != "hello world" {
+		t.Fatal(v, err)
+	}
+
+	if v, err := doc.Evaluate("upper-case(//title)"); err != nil || v != "HELLO WORLD" {
+		t.Fatal(v, err)
+	}
+
+	nodes, err = doc.QueryAll("//a[normalize-unicode(., 'NFKC') = 'ABC']")
+	if err != nil || len(nodes) != 1 {
+		t.Fatal(nodes, err)
+	}
+
+	// 虚拟属性不会出现在 @* 的结果中
+	nodes, err = doc.QueryAll("//a[has-class('link')]/@*")
+	if err != nil || len(nodes) != 2 {
+		t.Fatal(nodes, err)
+	}
+}
+
+func TestRegisterFunction(t *testing.T) {
+	doc := loadHTML(`<html><body><ul><li>10 USD</li><li>25 USD</li><li>3 USD</li></ul></body></html>`)
+
+	fs := NewFunctions()
+	fs.Register("price", FuncNumber, func(ctx *Node, args ...interface{}) interface{} {
+		return NumberValue(strings.Fields(StringValue(args[0]))[0])
+	})
+
+	nodes, err := fs.QueryAll(doc, "//li[price(.) > 5]")
+	if err != nil || len(nodes) != 2 {
+		t.Fatal(nodes, err)
+	}
+
+	if v, err := fs.Evaluate(doc, "price(//li[2]) + price(//li[3])"); err != nil || v != 28.0 {
+		t.Fatal(v, err)
+	}
+
+	nodes, err = fs.QueryAll(doc, "//li[price(lower-case(.)) = 3]")
+	if err != nil || len(nodes) != 1 {
+		t.Fatal(nodes, err)
+	}
+
+	// 注册函数只作用于 fs
+	if _, err := doc.QueryAll("//li[price(.) > 5]"); err == nil {
+		t.Fatal("price() should not be exists in the default functions")
+	}
+
+	if _, err := fs.QueryAll(doc, "//li[price(.) > 5"); err == nil {
+		t.Fatal("err should not be nil")
+	}
+
+	if _, err := fs.QueryAll(doc, "//li[price('5 USD) > 5]"); err == nil {
+		t.Fatal("err should not be nil")
+	}
+}
+
+func TestFunctionsSharedCache(t *testing.T) {
+	expr := "//li[lower-case(.) = 'a']"
+	a, err := NewFunctions().getQuery(expr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 没有注册函数的集合共用编译的表达式
+	if b, _ := NewFunctions().getQuery(expr, nil); a != b {
+		t.Error("the compiled expression should be shared")
+	}
+
+	fs := NewFunctions()
+	fs.Register("price", FuncNumber, func(ctx *Node, args ...interface{}) interface{} { return 1.0 })
+	if c, _ := fs.getQuery(expr, nil); c == a {
+		t.Error("the registered functions should not share the cache")
+	}
+}
+
+func TestFunctionVirtualAttributes(t *testing.T) {
+	doc := loadHTML(`<html><body><a class="c" href="/ONE">Link</a><p>one</p><p>two</p><!--Note--></body></html>`)
+
+	count := func(expr string) int {
+		nodes, err := doc.QueryAll(expr)
+		if err != nil {
+			t.Fatal(expr, err)
+		}
+		return len(nodes)
+	}
+
+	// 虚拟属性不在 @* 里面
+	for _, expr := range []string{
+		"//a[count(@*)=2]",
+		"//a[has-class('') or true()][count(@*)=2]",
+		"//a[lower-case(@href)='/one'][count(attribute::*)=2]",
+		"//a[upper-case(.)='LINK'][count(@node())=2]",
+	} {
+		if n := count(expr); n != 1 {
+			t.Error(expr, n)
+		}
+	}
+	if n := count("//a[has-class('c')]/@*"); n != 2 {
+		t.Error(n)
+	}
+
+	// 文本 注释 上下文
+	if n := count("//text()[matches(., 'one')]"); n != 1 {
+		t.Error(n)
+	}
+	if n := count("//comment()[lower-case(.)='note' and lower-case()='note']"); n != 1 {
+		t.Error(n)
+	}
+	text := doc.FindOne("//p[2]/text()")
+	if v, err := text.Evaluate("upper-case(.)"); err != nil || v != "TWO" {
+		t.Error(v, err)
+	}
+
+	// 属性上下文的参数以属性计算
+	href := doc.FindOne("//a/@href")
+	if v, err := href.Evaluate("lower-case(.)"); err != nil || v != "/one" {
+		t.Error(v, err)
+	}
+	if n := count("//a/@href[lower-case(.)='/one']"); n != 1 {
+		t.Error(n)
+	}
+	if n := count("//a/@*[matches(., '^/')]"); n != 1 {
+		t.Error(n)
+	}
+	// 属性没有属性
+	if n := count("//a/@href/@*"); n != 0 {
+		t.Error(n)
+	}
+}
+
+func TestFunctionErrors(t *testing.T) {
+	doc := loadHTML(`<html><body><ul><li data-re="(">a</li><li data-re="b" data-form="XX">b</li></ul></body></html>`)
+
+	// 字面量的参数在编译时检查
+	for _, expr := range []string{
+		"//li[ext:matches(., '(')]",
+		"//li[matches(.)]",
+		"//li[matches(., 'a', 'x')]",
+		"//li[matches(., 'a', 'i', 'm')]",
+		"//li[normalize-unicode(., 'XX')]",
+		"lower-case('a', 'b')",
+	} {
+		if _, err := doc.QueryAll(expr); err == nil {
+			t.Error(expr, "should be error")
+		}
+		if _, err := doc.Evaluate(expr); err == nil {
+			t.Error(expr, "should be error")
+		}
+	}
+
+	// 其他参数在调用时出错, 返回error
+	for _, expr := range []string{"//li[matches(., @data-re)]", "//li[normalize-unicode(., @data-form)]"} {
+		if _, err := doc.QueryAll(expr); err == nil || !strings.Contains(err.Error(), "xpath: ") {
+			t.Error(expr, err)
+		}
+	}
+	if _, err := doc.Query("//li[matches(., @data-re)]"); err == nil {
+		t.Error("should be error")
+	}
+	if v, err := doc.Evaluate("count(//li[2][matches(., @data-re)])"); err != nil || v != float64(1) {
+		t.Error(v, err)
+	}
+
+	fs := NewFunctions()
+	fs.Register("fail", FuncBool, func(ctx *Node, args ...interface{}) interface{} {
+		panic("failed")
+	})
+	if _, err := fs.QueryAll(doc, "//li[fail()]"); err == nil || err.Error() != "xpath: fail(): failed" {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,205 @@
+package htmlquery
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// NewElement creates the element without parent. attrs are key value pairs. eg: NewElement("a", "href", "/")
+func NewElement(tag string, attrs ...string) *Node {
+	tag = strings.ToLower(tag)
+	n := &Node{Type: html.ElementNode, Data: tag, DataAtom: atom.Lookup([]byte(tag))}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		n.SetAttr(attrs[i], attrs[i+1])
+	}
+	return n
+}
+
+// NewText creates the text node without parent
+func NewText(text string) *Node {
+	return &Node{Type: html.TextNode, Data: text}
+}
+
+// SetAttr sets the value of the attribute, adds the attribute if not exists.
+// the attribute result(eg: //a/@href) sets the attribute key of its owner element, SetAttr("href", v) sets its own value
+func (n *Node) SetAttr(key, val string) {
+	if n.Type == AttributeNode {
+		if key == n.Data {
+			n.SetText(val)
+		} else if owner := n.OwnerElement(); owner != nil {
+			owner.SetAttr(key, val)
+		}
+		return
+	}
+	if attr := n.GetAttributeByKey(key); attr != nil {
+		attr.Val = val
+		return
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+// RemoveAttr removes the attributes of the key
+func (n *Node) RemoveAttr(key string) {
+	attrs := n.Attr[:0]
+	for _, attr := range n.Attr {
+		if attr.Key != key {
+			attrs = append(attrs, attr)
+		}
+	}
+	n.Attr = attrs
+}
+
+// AddClass adds the classes which are not in the class attribute. eg: AddClass("price", "sale")
+func (n *Node) AddClass(classes ...string) {
+	current := strings.Fields(n.getAttr("class"))
+	changed := false
+	for _, class := range classes {
+		for _, c := range strings.Fields(class) {
+			if !containsString(current, c) {
+				current = append(current, c)
+				changed = true
+			}
+		}
+	}
+	if changed {
+		n.SetAttr("class", strings.Join(current, " "))
+	}
+}
+
+// RemoveClass removes the classes from the class attribute
+func (n *Node) RemoveClass(classes ...string) {
+	if !n.hasAttr("class") {
+		return
+	}
+	var remove []string
+	for _, class := range classes {
+		remove = append(remove, strings.Fields(class)...)
+	}
+	var current []string
+	for _, c := range strings.Fields(n.getAttr("class")) {
+		if !containsString(remove, c) {
+			current = append(current, c)
+		}
+	}
+	n.SetAttr("class", strings.Join(current, " "))
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// SetText replaces the children with the text. the text(comment) node sets its data, the attribute result sets the value
+func (n *Node) SetText(text string) {
+	switch n.Type {
+	case html.TextNode, html.CommentNode:
+		n.Data = text
+	case AttributeNode:
+		if n.Parent != nil {
+			if i := n.Position(); i != -1 {
+				n.Parent.Attr[i].Val = text
+			}
+		}
+		n.FirstChild.Data = text
+	default:
+		for c := n.FirstChild; c != nil; c = n.FirstChild {
+			n.removeChild(c)
+		}
+		n.appendChild(&html.Node{Type: html.TextNode, Data: text})
+	}
+}
+
+// Remove removes the node(with its descendants) from the document. the attribute result is removed from its owner
+func (n *Node) Remove() {
+	if n.Parent == nil {
+		return
+	}
+	if n.Type == AttributeNode {
+		if i := n.Position(); i != -1 {
+			n.Parent.Attr = append(n.Parent.Attr[:i], n.Parent.Attr[i+1:]...)
+		}
+		return
+	}
+	n.Parent.RemoveChild((*html.Node)(n))
+}
+
+// ReplaceWith replaces the node with the nodes. the nodes in the document are moved
+func (n *Node) ReplaceWith(nodes ...*Node) {
+	if n.Parent == nil || n.Type == AttributeNode {
+		return
+	}
+	parent := (*Node)(n.Parent)
+	for _, c := range nodes {
+		if c != n {
+			parent.InsertBefore(c, n)
+		}
+	}
+	if !containsNode(nodes, n) {
+		n.Remove()
+	}
+}
+
+func containsNode(nodes []*Node, n *Node) bool {
+	for _, c := range nodes {
+		if c == n {
+			return true
+		}
+	}
+	return false
+}
+
+// AppendChild adds the child as the last child. the child in the document is moved
+func (n *Node) AppendChild(child *Node) {
+	n.InsertBefore(child, nil)
+}
+
+// InsertBefore inserts the child before the ref child, or as the last child if ref is nil. the child in the document is moved.
+// panics if ref is not the child of the node, the child is the node or its ancestor, or the child is attribute(document)
+func (n *Node) InsertBefore(child, ref *Node) {
+	if child.Type == AttributeNode || child.Type == html.DocumentNode {
+		panic("htmlquery: InsertBefore called for an attribute or document child")
+	}
+	if ref != nil && ref.Parent != (*html.Node)(n) {
+		panic("htmlquery: InsertBefore called for a ref node which is not the child")
+	}
+	for p := n; p != nil; p = p.GetParent() {
+		if p == child {
+			panic("htmlquery: InsertBefore called for the node itself or its ancestor")
+		}
+	}
+	if child == ref {
+		return
+	}
+	child.Remove()
+	if ref == nil {
+		n.appendChild((*html.Node)(child))
+	} else {
+		(*html.Node)(n).InsertBefore((*html.Node)(child), (*html.Node)(ref))
+	}
+}
+
+// Wrap puts the wrapper in the place of the node, and moves the node into the wrapper as its last child.
+// eg: n.Wrap(NewElement("div", "class", "box"))
+func (n *Node) Wrap(wrapper *Node) {
+	if n.Type == AttributeNode {
+		return
+	}
+	if n.Parent != nil {
+		(*Node)(n.Parent).InsertBefore(wrapper, n)
+	}
+	wrapper.AppendChild(n)
+}
+
+func (n *Node) appendChild(c *html.Node) {
+	(*html.Node)(n).AppendChild(c)
+}
+
+func (n *Node) removeChild(c *html.Node) {
+	(*html.Node)(n).RemoveChild(c)
+}
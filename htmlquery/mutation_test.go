@@ -0,0 +1,71 @@
+package htmlquery
+
+import (
+	"strings"
+	"testing"
+)
+
+func body(doc *Node) string {
+	return strings.TrimSuffix(strings.TrimPrefix(doc.FindOne("//body").OutputHTML(true), "<body>"), "</body>")
+}
+
+func TestAttributeWriteThrough(t *testing.T) {
+	doc := loadHTML(`<a href="/x" class="c" xml:lang="en">a</a>`)
+	a := doc.FindOne("//a")
+	a.GetAttributeByKey("href").Val = "/y"
+	a.GetAttributeByValue("c").SetValue("d")
+	a.Attribute("href").Key = "data-href"
+	if got := body(doc); got != `<a data-href="/y" class="d" xml:lang="en">a</a>` {
+		t.Error(got)
+	}
+
+	// 属性结果的 SetAttr 设置所属元素的属性
+	class := doc.FindOne("//a/@class")
+	class.SetAttr("title", "t")
+	class.SetAttr("class", "e")
+	if got := body(doc); got != `<a data-href="/y" class="e" xml:lang="en" title="t">a</a>` || class.Text() != "e" {
+		t.Error(got)
+	}
+}
+
+func TestMutation(t *testing.T) {
+	doc := loadHTML(`<div id="box"><p class="a">one</p><p>two</p><span>x</span></div>`)
+	p1, p2, span := doc.FindOne("//p[1]"), doc.FindOne("//p[2]"), doc.FindOne("//span")
+
+	p1.SetAttr("id", "p1")
+	p1.SetAttr("class", "b")
+	p1.AddClass("c d", "b")
+	p2.AddClass("e")
+	p1.RemoveClass("b")
+	span.RemoveAttr("none")
+	if got := body(doc); got != `<div id="box"><p class="c d" id="p1">one</p><p class="e">two</p><span>x</span></div>` {
+		t.Error(got)
+	}
+
+	p2.SetText("<2>")
+	span.ReplaceWith(NewElement("B", "title", "t"), NewText("!"))
+	p1.Wrap(NewElement("section"))
+	doc.FindOne("//section").InsertBefore(NewText("0"), p1)
+	doc.FindOne("//div").RemoveAttr("id")
+	if got := body(doc); got != `<div><section>0<p class="c d" id="p1">one</p></section><p class="e">&lt;2&gt;</p><b title="t"></b>!</div>` {
+		t.Error(got)
+	}
+
+	// 移动已经在文档中的节点
+	doc.FindOne("//b").AppendChild(p2)
+	p1.Remove()
+	doc.FindOne("//b/@title").SetText("title")
+	doc.FindOne("//p/@class").Remove()
+	if got := body(doc); got != `<div><section>0</section><b title="title"><p>&lt;2&gt;</p></b>!</div>` {
+		t.Error(got)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("append the ancestor should panic")
+			}
+		}()
+		p2.AppendChild(doc.FindOne("//div"))
+	}()
+}
@@ -0,0 +1,152 @@
+package htmlquery
+
+import (
+	"sort"
+
+	"golang.org/x/net/html"
+)
+
+// CompareDocumentOrder compares the document position of n and other.
+// return -1 if n is before other, 1 if n is after other, 0 if n == other or they are not in the same document.
+// the ancestor is before its descendants.
+func (n *Node) CompareDocumentOrder(other *Node) int {
+	if n.Identity() == other.Identity() {
+		return 0
+	}
+
+	// 提升到相同的深度, 再一起向上找到共同的父节点
+	a, b := (*html.Node)(n), (*html.Node)(other)
+	da, db := depth(a), depth(b)
+	for ; da > db; da-- {
+		a = a.Parent
+	}
+	for ; db > da; db-- {
+		b = b.Parent
+	}
+	if a == b { // 一个是另一个的祖先
+		if depth((*html.Node)(n)) < depth((*html.Node)(other)) {
+			return -1
+		}
+		return 1
+	}
+	for a.Parent != b.Parent {
+		a, b = a.Parent, b.Parent
+	}
+	if a.Parent == nil {
+		return 0
+	}
+
+	// 属性在所属元素之后, 子节点之前
+	switch an, bn := (*Node)(a), (*Node)(b); {
+	case an.IsAttribute() && bn.IsAttribute():
+		if an.Position() < bn.Position() {
+			return -1
+		} else if an.Position() > bn.Position() {
+			return 1
+		}
+		return 0
+	case an.IsAttribute():
+		return -1
+	case bn.IsAttribute():
+		return 1
+	}
+
+	for s := a.NextSibling; s != nil; s = s.NextSibling {
+		if s == b {
+			return -1
+		}
+	}
+	return 1
+}
+
+func depth(n *html.Node) int {
+	d := 0
+	for ; n.Parent != nil; n = n.Parent {
+		d++
+	}
+	return d
+}
+
+// documentIndex 文档节点的先序位置. 元素后面留出属性的位置, 属性在所属元素之后, 子节点之前
+type documentIndex map[*html.Node]int
+
+func newDocumentIndex(root *html.Node) documentIndex {
+	index := make(documentIndex)
+	pos := 0
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		index[n] = pos
+		pos += 1 + len(n.Attr)
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	return index
+}
+
+// position 属性结果的位置是所属元素的位置 + 1 + 属性的下标. 已经从所属元素删除的属性和所属元素位置相同
+func (index documentIndex) position(n *html.Node) int {
+	if n.Type == AttributeNode && n.Parent != nil {
+		return index[n.Parent] + 1 + (*Node)(n).Position()
+	}
+	return index[n]
+}
+
+// DocumentOrder removes the duplicate nodes and sorts the nodes in document order.
+// the nodes of the different documents are grouped by document, in the order of their first nodes.
+func DocumentOrder(nodes []*Node) []*Node {
+	if len(nodes) == 0 {
+		return nodes
+	}
+
+	seen := make(map[interface{}]struct{}, len(nodes))
+	result := make([]*Node, 0, len(nodes))
+	sorted := true
+	for _, n := range nodes {
+		if _, ok := seen[n.Identity()]; ok {
+			continue
+		}
+		seen[n.Identity()] = struct{}{}
+		// 去重之后只有不同文档的节点比较结果是0
+		if sorted && len(result) > 0 && result[len(result)-1].CompareDocumentOrder(n) >= 0 {
+			sorted = false
+		}
+		result = append(result, n)
+	}
+	if sorted {
+		return result
+	}
+
+	// 每个文档只遍历一次, 按 (文档, 位置) 排序
+	type orderedNode struct {
+		node     *Node
+		doc, pos int
+	}
+	docs := make(map[*html.Node]int)
+	var indexes []documentIndex
+	ordered := make([]orderedNode, len(result))
+	for i, n := range result {
+		root := (*html.Node)(n)
+		for root.Parent != nil {
+			root = root.Parent
+		}
+		doc, ok := docs[root]
+		if !ok {
+			doc = len(indexes)
+			docs[root] = doc
+			indexes = append(indexes, newDocumentIndex(root))
+		}
+		ordered[i] = orderedNode{n, doc, indexes[doc].position((*html.Node)(n))}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].doc != ordered[j].doc {
+			return ordered[i].doc < ordered[j].doc
+		}
+		return ordered[i].pos < ordered[j].pos
+	})
+	for i := range ordered {
+		result[i] = ordered[i].node
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package htmlquery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocumentOrder(t *testing.T) {
+	doc := loadHTML(`<html><body><div id="a"><p id="b"></p><p id="c"></p></div><div id="d"></div></body></html>`)
+	a, b, c, d := doc.FindOne("//*[@id='a']"), doc.FindOne("//*[@id='b']"), doc.FindOne("//*[@id='c']"), doc.FindOne("//*[@id='d']")
+
+	if a.CompareDocumentOrder(b) != -1 || b.CompareDocumentOrder(a) != 1 {
+		t.Error("ancestor should be before descendant")
+	}
+	if c.CompareDocumentOrder(b) != 1 || c.CompareDocumentOrder(d) != -1 || a.CompareDocumentOrder(a) != 0 {
+		t.Error("sibling order is error")
+	}
+
+	other := loadHTML(`<p></p>`)
+	if a.CompareDocumentOrder(other) != 0 {
+		t.Error("nodes of different documents are not comparable")
+	}
+
+	nodes := DocumentOrder([]*Node{d, c, a, c, b, d})
+	if len(nodes) != 4 || nodes[0] != a || nodes[1] != b || nodes[2] != c || nodes[3] != d {
+		t.Error(nodes)
+	}
+}
+
+func TestQueryAllSet(t *testing.T) {
+	doc := loadHTML(`<html><body><div id="a"><p id="b"></p><p id="c"></p></div><div id="d"></div></body></html>`)
+	nodes, err := doc.QueryAll("//*[@id='d'] | //p | //*[@id='a'] | //div")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, n := range nodes {
+		v, _ := n.AttributeValue("id")
+		ids = append(ids, v)
+	}
+	if len(ids) != 4 || ids[0] != "a" || ids[1] != "b" || ids[2] != "c" || ids[3] != "d" {
+		t.Error(ids)
+	}
+
+	nodes, err = doc.QueryAll("//p/@id | //p/@id")
+	if err != nil || len(nodes) != 2 {
+		t.Error(nodes, err)
+	}
+}
+
+func TestDocumentOrderIndex(t *testing.T) {
+	doc := loadHTML(`<html><body><div id="a" class="x"><p id="b"></p><p id="c"></p></div><div id="d"></div></body></html>`)
+	a, b, d := doc.FindOne("//*[@id='a']"), doc.FindOne("//*[@id='b']"), doc.FindOne("//*[@id='d']")
+	class, id := doc.FindOne("//div[@id='a']/@class"), doc.FindOne("//div[@id='a']/@id")
+
+	var ids []string
+	for _, n := range DocumentOrder([]*Node{d, b, class, a, id}) {
+		ids = append(ids, n.XPath())
+	}
+	want := "/html[1]/body[1]/div[1] /html[1]/body[1]/div[1]/@id /html[1]/body[1]/div[1]/@class /html[1]/body[1]/div[1]/p[1] /html[1]/body[1]/div[2]"
+	if got := strings.Join(ids, " "); got != want {
+		t.Error(got)
+	}
+
+	// 不同文档的节点按文档分组
+	other := loadHTML(`<p id="x"></p><p id="y"></p>`)
+	x, y := other.FindOne("//p[@id='x']"), other.FindOne("//p[@id='y']")
+	nodes := DocumentOrder([]*Node{y, d, x, a})
+	if nodes[0] != x || nodes[1] != y || nodes[2] != a || nodes[3] != d {
+		t.Error(nodes)
+	}
+
+	// 已经删除的属性结果不会panic
+	id.Remove()
+	if nodes := DocumentOrder([]*Node{b, id, a}); len(nodes) != 3 || nodes[2] != b {
+		t.Error(nodes)
+	}
+
+	var many []*Node
+	ul := NewElement("ul")
+	for i := 0; i < 5000; i++ {
+		li := NewElement("li")
+		ul.AppendChild(li)
+		many = append([]*Node{li}, many...)
+	}
+	many = DocumentOrder(many)
+	if many[0] != ul.First() || many[len(many)-1] != (*Node)(ul.LastChild) {
+		t.Error("the siblings are not sorted")
+	}
+}
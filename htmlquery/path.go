@@ -0,0 +1,214 @@
+package htmlquery
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// XPath the absolute positional xpath of the node. eg: /html[1]/body[1]/div[2]/a[1]
+// text and comment nodes end with text()[i] comment()[i], attribute results end with @key
+func (n *Node) XPath() string {
+	switch n.Type {
+	case html.DocumentNode:
+		return "/"
+	case AttributeNode:
+		if n.Parent == nil {
+			return "@" + n.Data
+		}
+		return (*Node)(n.Parent).XPath() + "/@" + n.Data
+	}
+
+	var steps []string
+	for c := n; c != nil && c.Type != html.DocumentNode; c = c.GetParent() {
+		steps = append(steps, c.positionalStep())
+	}
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return "/" + strings.Join(steps, "/")
+}
+
+// positionalStep eg: div[2] text()[1]
+func (n *Node) positionalStep() string {
+	same := func(c *html.Node) bool {
+		if n.Type == html.ElementNode {
+			return c.Type == html.ElementNode && c.Data == n.Data
+		}
+		return c.Type == n.Type
+	}
+
+	i := 1
+	for c := n.PrevSibling; c != nil; c = c.PrevSibling {
+		if same(c) {
+			i++
+		}
+	}
+
+	switch n.Type {
+	case html.TextNode:
+		return fmt.Sprintf("text()[%d]", i)
+	case html.CommentNode:
+		return fmt.Sprintf("comment()[%d]", i)
+	default:
+		return fmt.Sprintf("%s[%d]", n.nameTest(), i)
+	}
+}
+
+var regexpNCName = regexp.MustCompile(`^[A-Za-z_][\w.\-]*$`)
+
+// nameTest 标签名不能直接用于xpath的时候使用 *[name()='...']
+func (n *Node) nameTest() string {
+	if regexpNCName.MatchString(n.Data) {
+		return n.Data
+	}
+	return "*[name()=" + XPathLiteral(n.Data) + "]"
+}
+
+// XPathLiteral quotes s as xpath string literal. use concat() if s contains both ' and "
+func XPathLiteral(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	if !strings.Contains(s, `"`) {
+		return `"` + s + `"`
+	}
+	var parts []string
+	for i, part := range strings.Split(s, "'") {
+		if i > 0 {
+			parts = append(parts, `"'"`)
+		}
+		if part != "" {
+			parts = append(parts, "'"+part+"'")
+		}
+	}
+	return "concat(" + strings.Join(parts, ", ") + ")"
+}
+
+// RobustAttributes the attributes used by RobustXPath, in priority order. id and class are always used if they are stable
+var RobustAttributes = []string{"data-testid", "data-test", "data-qa", "itemprop", "name", "for", "role", "aria-label", "type", "title", "alt", "rel"}
+
+// unstable class names of states. eg: active selected
+var unstableTokens = map[string]bool{
+	"active": true, "selected": true, "current": true, "hover": true, "focus": true, "open": true,
+	"show": true, "hidden": true, "disabled": true, "checked": true, "visible": true, "collapsed": true,
+}
+
+var regexpStateToken = regexp.MustCompile(`^(is|has)-`)
+
+// isStableToken id or class looks like written by human, not generated. eg: css-1q2w3e sc-AxjAm ember123
+func isStableToken(s string) bool {
+	if s == "" || len(s) > 40 || unstableTokens[s] || regexpStateToken.MatchString(s) {
+		return false
+	}
+	for _, seg := range strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '_' || r == ':' }) {
+		var digits, letters int
+		for _, r := range seg {
+			if r >= '0' && r <= '9' {
+				digits++
+			} else {
+				letters++
+			}
+		}
+		if digits >= 3 || digits > 0 && letters > 0 && len(seg) >= 5 {
+			return false
+		}
+	}
+	return true
+}
+
+// robustSteps the candidate location steps of the element in priority order. eg: div[@id='x'] a[@title='go']
+func (n *Node) robustSteps() []string {
+	tag := n.nameTest()
+	var preds []string
+	if id := n.getAttr("id"); isStableToken(id) {
+		preds = append(preds, "[@id="+XPathLiteral(id)+"]")
+	}
+	for _, key := range RobustAttributes {
+		if v := n.getAttr(key); v != "" && len(v) <= 80 {
+			preds = append(preds, "[@"+key+"="+XPathLiteral(v)+"]")
+		}
+	}
+	for _, class := range strings.Fields(n.getAttr("class")) {
+		if isStableToken(class) {
+			preds = append(preds, "[contains(concat(' ', normalize-space(@class), ' '), "+XPathLiteral(" "+class+" ")+")]")
+		}
+	}
+
+	var steps []string
+	if len(preds) > 0 && strings.HasPrefix(preds[0], "[@id=") {
+		steps = append(steps, tag+preds[0])
+	}
+	steps = append(steps, tag)
+	for _, p := range preds {
+		steps = append(steps, tag+p)
+	}
+	if len(preds) > 6 {
+		preds = preds[:6]
+	}
+	for i := range preds {
+		for j := i + 1; j < len(preds); j++ {
+			steps = append(steps, tag+preds[i]+preds[j])
+		}
+	}
+	return steps
+}
+
+// RobustXPath the shortest xpath which selects exactly the node in its document, preferring id, stable classes and attributes
+// over positions. eg: //div[@id='main']//a[@title='next']. if no robust xpath is found, returns XPath()
+func (n *Node) RobustXPath() string {
+	switch n.Type {
+	case html.DocumentNode:
+		return "/"
+	case AttributeNode:
+		if n.Parent == nil {
+			return "@" + n.Data
+		}
+		return (*Node)(n.Parent).RobustXPath() + "/@" + n.Data
+	case html.ElementNode:
+	default:
+		if n.Parent == nil {
+			return n.XPath()
+		}
+		return (*Node)(n.Parent).RobustXPath() + "/" + n.positionalStep()
+	}
+
+	root := n
+	for root.Parent != nil {
+		root = root.GetParent()
+	}
+	selects := func(exp string, target *Node) bool {
+		nodes, err := root.QueryAll(exp)
+		return err == nil && len(nodes) == 1 && nodes[0] == target
+	}
+
+	steps := n.robustSteps()
+	for _, step := range steps {
+		if selects("//"+step, n) {
+			return "//" + step
+		}
+	}
+
+	// 以唯一的祖先元素为锚点
+	rel := "/" + n.positionalStep()
+	depth := 0
+	for a := n.GetParent(); a != nil && a.Type == html.ElementNode && depth < 8; a = a.GetParent() {
+		for _, astep := range a.robustSteps() {
+			anchor := "//" + astep
+			if !selects(anchor, a) {
+				continue
+			}
+			for _, step := range steps {
+				if selects(anchor+"//"+step, n) {
+					return anchor + "//" + step
+				}
+			}
+			return anchor + rel
+		}
+		rel = "/" + a.positionalStep() + rel
+		depth++
+	}
+	return n.XPath()
+}
@@ -0,0 +1,82 @@
+package htmlquery
+
+import (
+	"testing"
+)
+
+const pathSample = `<html><body>
+<div id="main">
+	<ul class="list css-1q2w3e4">
+		<li class="item active"><a href="/1" title="first">one</a></li>
+		<li class="item"><a href="/2">two</a><!-- c --></li>
+		<li class="item special"><a href="/3">three</a></li>
+	</ul>
+	<form><label for="q">Search</label><input name="q" type="text"></form>
+</div>
+<div id="ember1234"><p>generated</p><p>text</p></div>
+</body></html>`
+
+func TestNodeXPath(t *testing.T) {
+	doc := loadHTML(pathSample)
+
+	a := doc.FindOne("//li[2]/a")
+	if p := a.XPath(); p != "/html[1]/body[1]/div[1]/ul[1]/li[2]/a[1]" {
+		t.Error(p)
+	}
+
+	for _, exp := range []string{"//li[2]/a", "//li[2]/comment()", "//li[3]/a/text()", "//a[@title]/@title", "//input", "//p[2]"} {
+		n := doc.FindOne(exp)
+		nodes, err := doc.QueryAll(n.XPath())
+		if err != nil || len(nodes) != 1 || nodes[0].Identity() != n.Identity() {
+			t.Error(exp, n.XPath(), nodes, err)
+		}
+	}
+}
+
+func TestRobustXPath(t *testing.T) {
+	doc := loadHTML(pathSample)
+
+	for _, exp := range []string{"//div[@id='main']", "//input", "//label", "//a[@title='first']", "//li[3]",
+		"//li[2]/a", "//p[2]", "//li[2]/a/text()", "//input/@name", "//ul", "//p[text()='text']/.."} {
+		n := doc.FindOne(exp)
+		robust := n.RobustXPath()
+		nodes, err := doc.QueryAll(robust)
+		if err != nil || len(nodes) != 1 || nodes[0].Identity() != n.Identity() {
+			t.Error(exp, robust, nodes, err)
+		}
+	}
+
+	cases := map[string]string{
+		"//div[@id='main']":   "//div[@id='main']",
+		"//input":             "//input",
+		"//a[@title='first']": "//a[@title='first']",
+		"//li[3]":             "//li[contains(concat(' ', normalize-space(@class), ' '), ' special ')]",
+		"//li[2]/a":           "//ul/li[2]/a[1]",
+		"//input/@name":       "//input/@name",
+		"//li[2]/a/text()":    "//ul/li[2]/a[1]/text()[1]",
+	}
+	for exp, want := range cases {
+		if p := doc.FindOne(exp).RobustXPath(); p != want {
+			t.Error(exp, p)
+		}
+	}
+
+	// 生成的id不使用
+	if p := doc.FindOne("//p[2]/..").RobustXPath(); p == "//div[@id='ember1234']" {
+		t.Error(p)
+	}
+}
+
+func TestXPathLiteral(t *testing.T) {
+	doc := loadHTML(`<p title="it's &quot;x&quot;"></p>`)
+	for _, s := range []string{"abc", "it's", `say "x"`, `it's "x"`} {
+		if s == `it's "x"` {
+			if n := doc.FindOne("//p[@title=" + XPathLiteral(s) + "]"); n == nil {
+				t.Error(XPathLiteral(s))
+			}
+		}
+		if v, err := doc.Evaluate("string(" + XPathLiteral(s) + ")"); err != nil || v != s {
+			t.Error(v, err)
+		}
+	}
+}
@@ -119,18 +119,11 @@ func LoadDoc(path string) (*Node, error) {
 }
 
 func getCurrentNode(n *NodeNavigator) *Node {
+	if n.curr.Type == AttributeNode {
+		return (*Node)(n.curr)
+	}
 	if n.NodeType() == xpath.AttributeNode {
-		childNode := &html.Node{
-			Type: html.TextNode,
-			Data: n.Value(),
-		}
-		return &Node{
-			Type:       html.ElementNode,
-			Data:       n.LocalName(),
-			FirstChild: childNode,
-			LastChild:  childNode,
-		}
-
+		return newAttributeNode(n.curr, n.attr)
 	}
 	return (*Node)(n.curr)
 }
@@ -197,6 +190,34 @@ func InnerText(n *html.Node) string {
 type NodeNavigator struct {
 	root, curr *html.Node
 	attr       int
+	ctxAttr    int         // 属性上下文的虚拟属性: 上下文属性的位置, 否则是-1
+	moved      bool        // Copy 之后是否调用过 MoveToNextAttribute
+	calls      []*funcCall // 扩展函数. 上下文节点(元素 文档 文本 注释 属性)的虚拟属性 @xf:f0 @xf:f1 ...
+	vars       []boundVar  // 绑定的变量. 根节点的虚拟属性 @xv:name
+}
+
+// isVirtualAttr 当前是扩展函数或者变量的虚拟属性
+func (h *NodeNavigator) isVirtualAttr() bool {
+	return h.attr != -1 && h.attr >= len(h.curr.Attr)
+}
+
+// attrCount 属性数量 包含虚拟属性
+func (h *NodeNavigator) attrCount() int {
+	count := len(h.curr.Attr) + len(h.calls)
+	if h.curr == h.root {
+		count += len(h.vars)
+	}
+	return count
+}
+
+// virtualAttr 当前虚拟属性的 prefix name value
+func (h *NodeNavigator) virtualAttr() (prefix, name string, value func() string) {
+	i := h.attr - len(h.curr.Attr)
+	if i < len(h.calls) {
+		return funcAttrPrefix, fmt.Sprintf("f%d", i), func() string { return h.calls[i].value(h) }
+	}
+	v := h.vars[i-len(h.calls)]
+	return varAttrPrefix, v.name, func() string { return v.value }
 }
 
 func (h *NodeNavigator) Current() *Node {
@@ -206,10 +227,19 @@ func (h *NodeNavigator) Current() *Node {
 func (h *NodeNavigator) NodeType() xpath.NodeType {
 	switch h.curr.Type {
 	case html.CommentNode:
+		if h.attr != -1 {
+			return xpath.AttributeNode
+		}
 		return xpath.CommentNode
 	case html.TextNode:
+		if h.attr != -1 {
+			return xpath.AttributeNode
+		}
 		return xpath.TextNode
 	case html.DocumentNode:
+		if h.attr != -1 {
+			return xpath.AttributeNode
+		}
 		return xpath.RootNode
 	case html.ElementNode:
 		if h.attr != -1 {
@@ -219,22 +249,36 @@ func (h *NodeNavigator) NodeType() xpath.NodeType {
 	case html.DoctypeNode:
 		// ignored <!DOCTYPE HTML> declare and as Root-Node type.
 		return xpath.RootNode
+	case AttributeNode: // 脱离了owner的属性结果
+		return xpath.AttributeNode
 	}
 	panic(fmt.Sprintf("unknown HTML node type: %v", h.curr.Type))
 }
 
 func (h *NodeNavigator) LocalName() string {
 	if h.attr != -1 {
+		if h.isVirtualAttr() {
+			_, name, _ := h.virtualAttr()
+			return name
+		}
 		return h.curr.Attr[h.attr].Key
 	}
 	return h.curr.Data
 }
 
-func (*NodeNavigator) Prefix() string {
+func (h *NodeNavigator) Prefix() string {
+	if h.isVirtualAttr() {
+		prefix, _, _ := h.virtualAttr()
+		return prefix
+	}
 	return ""
 }
 
 func (h *NodeNavigator) Value() string {
+	if h.isVirtualAttr() {
+		_, _, value := h.virtualAttr()
+		return value()
+	}
 	switch h.curr.Type {
 	case html.CommentNode:
 		return h.curr.Data
@@ -245,32 +289,48 @@ func (h *NodeNavigator) Value() string {
 		return InnerText(h.curr)
 	case html.TextNode:
 		return h.curr.Data
+	case AttributeNode:
+		return InnerText(h.curr)
 	}
 	return ""
 }
 
 func (h *NodeNavigator) Copy() xpath.NodeNavigator {
 	n := *h
+	n.moved = false
 	return &n
 }
 
 func (h *NodeNavigator) MoveToRoot() {
 	h.curr = h.root
+	h.attr, h.ctxAttr = -1, -1
 }
 
 func (h *NodeNavigator) MoveToParent() bool {
 	if h.attr != -1 {
-		h.attr = -1
+		h.attr, h.ctxAttr = -1, -1
 		return true
-	} else if node := h.curr.Parent; node != nil {
+	} else if node := h.curr.Parent; node != nil && h.curr.Type != AttributeNode {
 		h.curr = node
 		return true
 	}
 	return false
 }
 
+// MoveToNextAttribute the attribute axis copies the context navigator then moves it.
+// the attributes of the attribute context(eg: //a/@href[lower-case(.)='x']) are only the virtual attributes
 func (h *NodeNavigator) MoveToNextAttribute() bool {
-	if h.attr >= len(h.curr.Attr)-1 {
+	moved := h.moved
+	h.moved = true
+	if !moved && h.attr != -1 && !h.isVirtualAttr() {
+		if len(h.calls) == 0 {
+			return false
+		}
+		h.ctxAttr = h.attr
+		h.attr = len(h.curr.Attr)
+		return true
+	}
+	if h.attr >= h.attrCount()-1 {
 		return false
 	}
 	h.attr++
@@ -278,7 +338,7 @@ func (h *NodeNavigator) MoveToNextAttribute() bool {
 }
 
 func (h *NodeNavigator) MoveToChild() bool {
-	if h.attr != -1 {
+	if h.attr != -1 || h.curr.Type == AttributeNode {
 		return false
 	}
 	if node := h.curr.FirstChild; node != nil {
@@ -336,5 +396,6 @@ func (h *NodeNavigator) MoveTo(other xpath.NodeNavigator) bool {
 
 	h.curr = node.curr
 	h.attr = node.attr
+	h.ctxAttr = node.ctxAttr
 	return true
 }
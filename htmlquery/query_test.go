@@ -69,7 +69,7 @@ func TestSelectorCache(t *testing.T) {
 
 func TestLoadURL(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintln(w, htmlSample)
+		fmt.Fprint(w, htmlSample)
 	}))
 	defer ts.Close()
 
@@ -238,3 +238,108 @@ func TestComment(t *testing.T) {
 	// t.Error(doc)
 
 }
+
+func TestAttributeResult(t *testing.T) {
+	doc := loadHTML(`<html><body><a id="x" href="/a?b=1&amp;c=2">go<i>!</i></a><a href="/b">rust</a></body></html>`)
+
+	hrefs, err := doc.QueryAll("//a/@href")
+	if err != nil || len(hrefs) != 2 {
+		t.Fatal(hrefs, err)
+	}
+
+	href := hrefs[0]
+	if !href.IsAttribute() || href.IsElement() || href.Text() != "/a?b=1&c=2" || href.Position() != 1 {
+		t.Error(href.Text(), href.Position())
+	}
+	if owner := href.OwnerElement(); owner == nil || owner.Data != "a" || owner.FirstChild.Data != "go" {
+		t.Error("owner element is error")
+	}
+	if _, err := href.TagName(); err == nil {
+		t.Error("attribute is not element")
+	}
+	if v, err := href.AttributeValue("href"); err != nil || v != "/a?b=1&c=2" {
+		t.Error(v, err)
+	}
+	if href.String() != `href="/a?b=1&amp;c=2"` {
+		t.Error(href.String())
+	}
+	if attr := href.AsAttribute(); attr == nil || attr.Key != "href" {
+		t.Error(attr)
+	}
+
+	// 属性结果可以回到所属的元素
+	if n := href.FindOne(".."); n == nil || n.Data != "a" || n.FirstChild.Data != "go" {
+		t.Error(n)
+	}
+	if nodes := href.Find("../i"); len(nodes) != 1 {
+		t.Error(nodes)
+	}
+
+	// 不同查询选择的同一个属性是同一个节点
+	again := doc.FindOne("//a[@id='x']/@href")
+	if again.Identity() != href.Identity() || len(DocumentOrder([]*Node{href, again})) != 1 {
+		t.Error("the same attribute should have the same identity")
+	}
+
+	// 文档顺序: 元素 -> 属性 -> 子节点
+	nodes, err := doc.QueryAll("//a[1]/i | //a[1]/@href | //a[1] | //a[1]/@id")
+	if err != nil || len(nodes) != 4 || nodes[0].Data != "a" || nodes[1].Data != "id" || nodes[2].Data != "href" || nodes[3].Data != "i" {
+		t.Error(nodes, err)
+	}
+
+	text := doc.FindOne("//a[2]/text()")
+	if !text.IsText() || text.OwnerElement().Data != "a" || text.Position() != 0 || text.Text() != "rust" {
+		t.Error(text)
+	}
+}
+
+func TestDetachedAttributeResult(t *testing.T) {
+	doc := loadHTML(`<html><body><a href="/a" id="x">go</a></body></html>`)
+	href := doc.FindOne("//a/@href")
+	href.OwnerElement().RemoveAttr("href")
+	if href.Position() != -1 {
+		t.Fatal(href.Position())
+	}
+
+	// 脱离owner的属性结果可以查询, 不会panic
+	nodes, err := href.QueryAll(".")
+	if err != nil || len(nodes) != 1 || nodes[0] != href {
+		t.Error(nodes, err)
+	}
+	if v, err := href.Evaluate("string(.)"); err != nil || v != "/a" {
+		t.Error(v, err)
+	}
+	if v, err := href.Evaluate("upper-case(.)"); err != nil || v != "/A" {
+		t.Error(v, err)
+	}
+	if n := href.FindOne(".."); n != nil {
+		t.Error(n)
+	}
+	if nodes := href.Find("node()"); len(nodes) != 0 {
+		t.Error(nodes)
+	}
+
+	id := doc.FindOne("//a/@id")
+	id.Remove()
+	if v, err := id.Evaluate("name()"); err != nil || v != "id" {
+		t.Error(v, err)
+	}
+}
+
+func TestClone(t *testing.T) {
+	doc := loadHTML(`<div id="a"><p class="x">hello <b>world</b></p></div>`)
+	div := doc.FindOne("//div")
+	c := div.Clone()
+	if c.Parent != nil || c.OutputHTML(true) != div.OutputHTML(true) {
+		t.Error(c.OutputHTML(true))
+	}
+
+	c.FindOne("//b").Data = "i"
+	c.FindOne("//p").Attr[0].Val = "y"
+	if div.OutputHTML(true) != `<div id="a"><p class="x">hello <b>world</b></p></div>` {
+		t.Error(div.OutputHTML(true))
+	}
+	if n := c.FindOne("//p[@class='y']/i"); n == nil || n.Text() != "world" {
+		t.Error(c.OutputHTML(true))
+	}
+}
@@ -0,0 +1,388 @@
+package htmlquery
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// HTMLFormat the format of RenderHTML. the flags can be combined. eg: HTMLInner | HTMLMinify
+type HTMLFormat int
+
+// HTMLOuter the node itself like html.Render
+const HTMLOuter HTMLFormat = 0
+
+const (
+	// HTMLInner the child nodes, not the node itself
+	HTMLInner HTMLFormat = 1 << iota
+	// HTMLPretty indented by 2 spaces, the block elements start on new lines. the elements only containing inline content are on one line
+	HTMLPretty
+	// HTMLMinify collapses whitespace, removes comments, the whitespace between blocks and the optional end tags. HTMLPretty is ignored
+	HTMLMinify
+)
+
+// OuterHTML the html of the node itself. the attribute result is key="value"
+func (n *Node) OuterHTML() string {
+	return n.RenderHTML(HTMLOuter)
+}
+
+// InnerHTML the html of the child nodes. the attribute result is the escaped value
+func (n *Node) InnerHTML() string {
+	return n.RenderHTML(HTMLInner)
+}
+
+// PrettyHTML the indented html of the node itself. eg: mth:"PrettyHTML"
+func (n *Node) PrettyHTML() string {
+	return n.RenderHTML(HTMLPretty)
+}
+
+// MinifiedHTML the minified html of the node itself. eg: mth:"MinifiedHTML"
+func (n *Node) MinifiedHTML() string {
+	return n.RenderHTML(HTMLMinify)
+}
+
+// FormatHTML renders html by the format names: inner outer pretty minify, for mth tags. panics if the name is unknown.
+// eg: mth:"FormatHTML,inner,pretty"
+func (n *Node) FormatHTML(names ...string) string {
+	var format HTMLFormat
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "inner":
+			format |= HTMLInner
+		case "outer", "":
+		case "pretty":
+			format |= HTMLPretty
+		case "minify", "minified":
+			format |= HTMLMinify
+		default:
+			panic(fmt.Errorf("html format %s is not exists", name))
+		}
+	}
+	return n.RenderHTML(format)
+}
+
+// RenderHTML renders the node(or its child nodes with HTMLInner) in the format
+func (n *Node) RenderHTML(format HTMLFormat) string {
+	if n.Type == AttributeNode {
+		if format&HTMLInner != 0 {
+			return html.EscapeString(n.InnerText())
+		}
+		return n.Data + `="` + html.EscapeString(n.InnerText()) + `"`
+	}
+
+	var nodes []*html.Node
+	if format&HTMLInner != 0 {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			nodes = append(nodes, c)
+		}
+	} else {
+		nodes = append(nodes, (*html.Node)(n))
+	}
+
+	r := &htmlRenderer{top: (*html.Node)(n)}
+	for _, c := range nodes {
+		switch {
+		case format&HTMLMinify != 0:
+			r.collapsed(c, true)
+		case format&HTMLPretty != 0:
+			r.pretty(c, 0)
+		default:
+			html.Render(&r.buf, c)
+		}
+	}
+	if format&(HTMLPretty|HTMLMinify) == HTMLPretty {
+		return strings.TrimSuffix(r.buf.String(), "\n")
+	}
+	return r.buf.String()
+}
+
+// voidTags 没有结束标签的元素
+var voidTags = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"keygen": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// literalTags 子节点的文本原样输出(和html.Render一致)
+var literalTags = map[string]bool{
+	"iframe": true, "noembed": true, "noframes": true, "noscript": true, "plaintext": true, "script": true, "style": true, "xmp": true,
+}
+
+// preformattedTags 空白有意义的元素, pretty时原样输出
+var preformattedTags = map[string]bool{
+	"pre": true, "textarea": true, "listing": true, "plaintext": true, "xmp": true, "script": true, "style": true,
+}
+
+// blockTags pretty时另起一行的元素, minify时它们之间的空白会删除
+var blockTags = map[string]bool{
+	"html": true, "head": true, "body": true, "title": true, "meta": true, "link": true, "base": true, "script": true,
+	"style": true, "noscript": true, "template": true,
+	"address": true, "article": true, "aside": true, "blockquote": true, "details": true, "dialog": true, "dd": true,
+	"div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true, "figure": true, "footer": true,
+	"form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hgroup": true, "hr": true, "li": true, "main": true, "menu": true, "nav": true, "ol": true, "p": true,
+	"pre": true, "section": true, "summary": true, "table": true, "caption": true, "colgroup": true, "thead": true,
+	"tbody": true, "tfoot": true, "tr": true, "td": true, "th": true, "ul": true,
+}
+
+// spaceInsignificantTags 这些元素的直接子节点的空白没有意义
+var spaceInsignificantTags = map[string]bool{
+	"html": true, "head": true, "table": true, "thead": true, "tbody": true, "tfoot": true, "tr": true,
+	"colgroup": true, "ul": true, "ol": true, "dl": true, "select": true, "optgroup": true,
+}
+
+// pClosers the elements close the p element, the end tag of p before them can be omitted
+var pClosers = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "details": true, "div": true, "dl": true,
+	"fieldset": true, "figcaption": true, "figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hgroup": true, "hr": true, "main": true,
+	"menu": true, "nav": true, "ol": true, "p": true, "pre": true, "section": true, "table": true, "ul": true,
+}
+
+type htmlRenderer struct {
+	buf bytes.Buffer
+	top *html.Node // 渲染的节点, 它的结束标签不省略
+}
+
+// pretty 块元素缩进, 只有行内内容的元素在一行
+func (r *htmlRenderer) pretty(n *html.Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch n.Type {
+	case html.DocumentNode:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			r.pretty(c, depth)
+		}
+	case html.TextNode:
+		if literalParent(n) || preserveSpace(n) {
+			r.buf.WriteString(indent + n.Data + "\n")
+			return
+		}
+		if text := strings.TrimSpace(CollapseSpace(n.Data)); text != "" {
+			r.buf.WriteString(indent + html.EscapeString(text) + "\n")
+		}
+	case html.ElementNode:
+		r.buf.WriteString(indent)
+		switch {
+		case n.Namespace != "" || preformattedTags[n.Data]:
+			html.Render(&r.buf, n)
+		case inlineContent(n):
+			r.startTag(n, false)
+			if !voidTags[n.Data] {
+				inner := &htmlRenderer{top: r.top}
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					inner.collapsed(c, false)
+				}
+				r.buf.WriteString(strings.TrimSpace(inner.buf.String()))
+				r.buf.WriteString("</" + n.Data + ">")
+			}
+		default:
+			r.startTag(n, false)
+			r.buf.WriteString("\n")
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				r.pretty(c, depth+1)
+			}
+			r.buf.WriteString(indent + "</" + n.Data + ">")
+		}
+		r.buf.WriteString("\n")
+	default:
+		r.buf.WriteString(indent)
+		html.Render(&r.buf, n)
+		r.buf.WriteString("\n")
+	}
+}
+
+// collapsed 连续的空白换成一个空格(pre textarea除外). minify时删除注释, 块元素之间的空白和可以省略的结束标签
+func (r *htmlRenderer) collapsed(n *html.Node, minify bool) {
+	switch n.Type {
+	case html.TextNode:
+		switch {
+		case literalParent(n):
+			r.buf.WriteString(n.Data)
+		case preserveSpace(n):
+			r.buf.WriteString(html.EscapeString(n.Data))
+		case minify && droppableSpace(n):
+		default:
+			r.buf.WriteString(html.EscapeString(CollapseSpace(n.Data)))
+		}
+	case html.CommentNode:
+		if !minify {
+			html.Render(&r.buf, n)
+		}
+	case html.DocumentNode:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			r.collapsed(c, minify)
+		}
+	case html.ElementNode:
+		if n.Namespace != "" { // svg math
+			html.Render(&r.buf, n)
+			return
+		}
+		r.startTag(n, minify)
+		if voidTags[n.Data] {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			r.collapsed(c, minify)
+		}
+		if !minify || n == r.top || !optionalEndTag(n) {
+			r.buf.WriteString("</" + n.Data + ">")
+		}
+	default:
+		html.Render(&r.buf, n)
+	}
+}
+
+// startTag minify时空值的属性只写名字. eg: <input disabled>
+func (r *htmlRenderer) startTag(n *html.Node, minify bool) {
+	r.buf.WriteString("<" + n.Data)
+	for _, attr := range n.Attr {
+		r.buf.WriteByte(' ')
+		if attr.Namespace != "" {
+			r.buf.WriteString(attr.Namespace + ":")
+		}
+		r.buf.WriteString(attr.Key)
+		if !minify || attr.Val != "" {
+			r.buf.WriteString(`="` + html.EscapeString(attr.Val) + `"`)
+		}
+	}
+	r.buf.WriteString(">")
+	// 解析时会去掉开始标签后的第一个换行, 和html.Render一样补上
+	switch n.Data {
+	case "pre", "listing", "textarea":
+		if c := n.FirstChild; c != nil && c.Type == html.TextNode && strings.HasPrefix(c.Data, "\n") {
+			r.buf.WriteString("\n")
+		}
+	}
+}
+
+// inlineContent 子孙节点没有块元素和预格式化的元素
+func inlineContent(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (blockTags[c.Data] || preformattedTags[c.Data] || !inlineContent(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+func literalParent(n *html.Node) bool {
+	p := n.Parent
+	return p != nil && p.Type == html.ElementNode && p.Namespace == "" && literalTags[p.Data]
+}
+
+func preserveSpace(n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && (p.Data == "pre" || p.Data == "textarea" || p.Data == "listing") {
+			return true
+		}
+	}
+	return false
+}
+
+func isSpace(s string) bool {
+	return strings.Trim(s, " \t\r\n\f") == ""
+}
+
+// droppableSpace 空白文本在块元素之间, 或者在空白没有意义的元素里面
+func droppableSpace(n *html.Node) bool {
+	if !isSpace(n.Data) {
+		return false
+	}
+	p := n.Parent
+	if p == nil || p.Type != html.ElementNode || spaceInsignificantTags[p.Data] {
+		return true
+	}
+	// 跳过注释和空白文本
+	isBlock := func(s *html.Node, next func(*html.Node) *html.Node) bool {
+		for s != nil && (s.Type == html.CommentNode || s.Type == html.TextNode && isSpace(s.Data)) {
+			s = next(s)
+		}
+		if s == nil {
+			return blockTags[p.Data]
+		}
+		return s.Type == html.ElementNode && blockTags[s.Data]
+	}
+	return isBlock(n.PrevSibling, func(s *html.Node) *html.Node { return s.PrevSibling }) &&
+		isBlock(n.NextSibling, func(s *html.Node) *html.Node { return s.NextSibling })
+}
+
+// nextRendered minify时后面第一个输出的兄弟节点
+func nextRendered(n *html.Node) *html.Node {
+	for s := n.NextSibling; s != nil; s = s.NextSibling {
+		if s.Type == html.CommentNode || s.Type == html.TextNode && droppableSpace(s) {
+			continue
+		}
+		return s
+	}
+	return nil
+}
+
+// optionalEndTag the end tag can be omitted. see https://html.spec.whatwg.org/multipage/syntax.html#optional-tags
+func optionalEndTag(n *html.Node) bool {
+	next := nextRendered(n)
+	var tag string
+	if next != nil && next.Type == html.ElementNode {
+		tag = next.Data
+	}
+	switch n.Data {
+	case "html", "head", "body":
+		return true
+	case "li":
+		return next == nil || tag == "li"
+	case "dt":
+		return tag == "dt" || tag == "dd"
+	case "dd":
+		return next == nil || tag == "dt" || tag == "dd"
+	case "p":
+		if next == nil {
+			switch n.Parent.Data {
+			case "a", "audio", "del", "ins", "map", "noscript", "video":
+				return false
+			}
+			return true
+		}
+		return pClosers[tag]
+	case "rt", "rp":
+		return next == nil || tag == "rt" || tag == "rp"
+	case "optgroup":
+		return next == nil || tag == "optgroup"
+	case "option":
+		return next == nil || tag == "option" || tag == "optgroup"
+	case "thead":
+		return tag == "tbody" || tag == "tfoot"
+	case "tbody":
+		return next == nil || tag == "tbody" || tag == "tfoot"
+	case "tfoot":
+		return next == nil
+	case "tr":
+		return next == nil || tag == "tr"
+	case "td", "th":
+		return next == nil || tag == "td" || tag == "th"
+	}
+	return false
+}
+
+// CollapseSpace replaces the consecutive whitespace with one space, the leading and trailing space are kept. eg: " a \n b" -> " a b"
+func CollapseSpace(s string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range s {
+		switch r {
+		case ' ', '\t', '\n', '\r', '\f':
+			if !space {
+				sb.WriteByte(' ')
+			}
+			space = true
+		default:
+			sb.WriteRune(r)
+			space = false
+		}
+	}
+	return sb.String()
+}
+
+// NormalizeSpace trims the whitespace and replaces the consecutive whitespace with one space like xpath normalize-space()
+func NormalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,100 @@
+package htmlquery
+
+import (
+	"strings"
+	"testing"
+)
+
+const renderHTML = `<!DOCTYPE html><html><head><title>T</title><style>p > a { }</style></head><body>
+	<!-- c -->
+	<div id="main">
+		<p>Hello   <b>big</b>
+		world &amp; "you"</p>
+		<ul>
+			<li>one</li>
+			<li>two <a href="/x?a=1&b=2">link</a></li>
+		</ul>
+		<pre>
+  a   b</pre>
+		<input disabled value="">
+		<table><tr><td>1</td><td>2</td></tr></table>
+		<span>a</span> <span>b</span>
+	</div>
+</body></html>`
+
+func TestInnerOuterHTML(t *testing.T) {
+	doc := loadHTML(`<div id="d"><p>a</p>b<!--c--></div>`)
+	div := doc.FindOne("//div")
+	if got := div.InnerHTML(); got != `<p>a</p>b<!--c-->` || div.OutputHTML(false) != got {
+		t.Error(got)
+	}
+	if got := div.OuterHTML(); got != `<div id="d"><p>a</p>b<!--c--></div>` || div.OutputHTML(true) != got {
+		t.Error(got)
+	}
+	id := doc.FindOne("//div/@id")
+	if id.OuterHTML() != `id="d"` || id.InnerHTML() != "d" {
+		t.Error(id.OuterHTML(), id.InnerHTML())
+	}
+	if got := doc.FindOne("//p/text()").InnerHTML(); got != "" {
+		t.Error(got)
+	}
+}
+
+func TestPrettyHTML(t *testing.T) {
+	doc := loadHTML(renderHTML)
+	expected := `<div id="main">
+  <p>Hello <b>big</b> world &amp; &#34;you&#34;</p>
+  <ul>
+    <li>one</li>
+    <li>two <a href="/x?a=1&amp;b=2">link</a></li>
+  </ul>
+  <pre>  a   b</pre>
+  <input disabled="" value="">
+  <table>
+    <tbody>
+      <tr>
+        <td>1</td>
+        <td>2</td>
+      </tr>
+    </tbody>
+  </table>
+  <span>a</span>
+  <span>b</span>
+</div>`
+	if got := doc.FindOne("//div").PrettyHTML(); got != expected {
+		t.Error(got)
+	}
+	if got := doc.FindOne("//ul").FormatHTML("inner", "pretty"); got != "<li>one</li>\n<li>two <a href=\"/x?a=1&amp;b=2\">link</a></li>" {
+		t.Error(got)
+	}
+	if got := doc.PrettyHTML(); !strings.HasPrefix(got, "<!DOCTYPE html>\n<html>\n  <head>\n    <title>T</title>\n    <style>p > a { }</style>\n  </head>\n  <body>\n    <!-- c -->\n") {
+		t.Error(got)
+	}
+}
+
+func TestMinifiedHTML(t *testing.T) {
+	doc := loadHTML(renderHTML)
+	expected := `<!DOCTYPE html><html><head><title>T</title><style>p > a { }</style><body><div id="main"><p>Hello <b>big</b> world &amp; &#34;you&#34;` +
+		`<ul><li>one<li>two <a href="/x?a=1&amp;b=2">link</a></ul><pre>  a   b</pre> <input disabled value> <table><tbody><tr><td>1<td>2</table> <span>a</span> <span>b</span> </div>`
+	got := doc.MinifiedHTML()
+	if got != expected {
+		t.Error(got)
+	}
+	// 重新解析的结果相同
+	if again := loadHTML(got).MinifiedHTML(); again != got {
+		t.Error(again)
+	}
+	if got := doc.FindOne("//li[1]").RenderHTML(HTMLMinify); got != "<li>one</li>" {
+		t.Error(got)
+	}
+	if got := doc.FindOne("//tr").FormatHTML("inner", "minify"); got != "<td>1<td>2" {
+		t.Error(got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("unknown format should panic")
+		}
+	}()
+	doc.FormatHTML("ugly")
+}
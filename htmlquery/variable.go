@@ -0,0 +1,71 @@
+package htmlquery
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// boundVar 绑定的变量. 以根节点的虚拟属性 @xv:name 提供给xpath
+type boundVar struct {
+	name  string
+	value string
+}
+
+// varType 变量值对应的xpath类型. 整数浮点数为 number, bool 为 boolean, 其他都以 string 绑定
+func varType(v interface{}) FuncType {
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return FuncNumber
+	case reflect.Bool:
+		return FuncBool
+	default:
+		return FuncString
+	}
+}
+
+func varTypes(vars map[string]interface{}) map[string]FuncType {
+	vtypes := make(map[string]FuncType, len(vars))
+	for name, v := range vars {
+		vtypes[name] = varType(v)
+	}
+	return vtypes
+}
+
+// varsSignature 变量名和类型组成的缓存key. 与变量的值无关
+func varsSignature(vars map[string]interface{}) string {
+	if len(vars) == 0 {
+		return ""
+	}
+	var sig []string
+	for name, v := range vars {
+		sig = append(sig, fmt.Sprintf("%s:%d", name, varType(v)))
+	}
+	sort.Strings(sig)
+	return strings.Join(sig, ",")
+}
+
+// bindVars 按表达式使用的变量顺序绑定值
+func bindVars(names []string, vars map[string]interface{}) []boundVar {
+	if len(names) == 0 {
+		return nil
+	}
+	bound := make([]boundVar, 0, len(names))
+	for _, name := range names {
+		v := vars[name]
+		var value string
+		switch varType(v) {
+		case FuncNumber:
+			value = StringValue(reflect.ValueOf(v).Convert(reflect.TypeOf(float64(0))).Float())
+		case FuncBool:
+			value = StringValue(reflect.ValueOf(v).Bool())
+		default:
+			value = StringValue(v)
+		}
+		bound = append(bound, boundVar{name: name, value: value})
+	}
+	return bound
+}
@@ -0,0 +1,76 @@
+package htmlquery
+
+import (
+	"testing"
+)
+
+func TestQueryAllWithVars(t *testing.T) {
+	doc := loadHTML(`<html><body>
+		<a data-id="1" title="it's">one</a>
+		<a data-id="2" title='say "hi"'>two</a>
+		<a data-id="3" title="x' or '1'='1">three</a>
+	</body></html>`)
+
+	nodes, err := doc.QueryAllWithVars("//a[@data-id=$id]", map[string]interface{}{"id": "2"})
+	if err != nil || len(nodes) != 1 || nodes[0].InnerText() != "two" {
+		t.Fatal(nodes, err)
+	}
+
+	nodes, err = doc.QueryAllWithVars("//a[@data-id=$id]", map[string]interface{}{"id": 3})
+	if err != nil || len(nodes) != 1 || nodes[0].InnerText() != "three" {
+		t.Fatal(nodes, err)
+	}
+
+	for _, title := range []string{`it's`, `say "hi"`, `x' or '1'='1`} {
+		nodes, err = doc.QueryAllWithVars("//a[@title=$title]", map[string]interface{}{"title": title})
+		if err != nil || len(nodes) != 1 {
+			t.Fatal(title, nodes, err)
+		}
+	}
+
+	nodes, err = doc.QueryAllWithVars("//a[@data-id=$id]", map[string]interface{}{"id": "' or '1'='1"})
+	if err != nil || len(nodes) != 0 {
+		t.Fatal(nodes, err)
+	}
+
+	// number 变量可以作为位置
+	nodes, err = doc.QueryAllWithVars("//a[$i]", map[string]interface{}{"i": 2})
+	if err != nil || len(nodes) != 1 || nodes[0].InnerText() != "two" {
+		t.Fatal(nodes, err)
+	}
+
+	nodes, err = doc.QueryAllWithVars("//a[$all or @data-id=$id]", map[string]interface{}{"all": false, "id": 1.0})
+	if err != nil || len(nodes) != 1 {
+		t.Fatal(nodes, err)
+	}
+
+	nodes, err = doc.QueryAllWithVars("//a[contains(lower-case(.), $q)]", map[string]interface{}{"q": "t"})
+	if err != nil || len(nodes) != 2 {
+		t.Fatal(nodes, err)
+	}
+
+	nodes, err = (*Node)(nodes[0]).QueryAllWithVars("./@*[. = $v]", map[string]interface{}{"v": "2"})
+	if err != nil || len(nodes) != 1 {
+		t.Fatal(nodes, err)
+	}
+
+	if _, err = doc.QueryAllWithVars("//a[@data-id=$id]", nil); err == nil {
+		t.Fatal("unbound variable should return error")
+	}
+}
+
+func TestVarsCache(t *testing.T) {
+	fs := NewFunctions()
+	e1, err := fs.getQuery("//a[@data-id=$id]", map[string]interface{}{"id": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2, _ := fs.getQuery("//a[@data-id=$id]", map[string]interface{}{"id": "2"})
+	if e1 != e2 {
+		t.Error("the compiled expression should be independent of the variable values")
+	}
+	e3, _ := fs.getQuery("//a[@data-id=$id]", map[string]interface{}{"id": 2})
+	if e1 == e3 {
+		t.Error("the compiled expression should depend on the variable types")
+	}
+}
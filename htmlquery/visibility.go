@@ -0,0 +1,144 @@
+package htmlquery
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// HiddenClasses the classes treated as hidden by IsHidden and VisibleText. it can be changed before extracting.
+// eg: HiddenClasses["is-hidden"] = true
+var HiddenClasses = map[string]bool{
+	"sr-only":            true,
+	"visually-hidden":    true,
+	"screen-reader-text": true,
+	"d-none":             true,
+	"hidden":             true,
+}
+
+// hiddenTags 内容不会显示的标签
+var hiddenTags = map[string]bool{
+	"head": true, "script": true, "style": true, "template": true, "noscript": true,
+}
+
+// Style the inline style declarations of the element. the property is lower case, the value is trimmed without !important.
+// eg: style="display: none; color:red" -> {"display": "none", "color": "red"}
+func (n *Node) Style() map[string]string {
+	style := make(map[string]string)
+	for _, decl := range splitStyle(n.getAttr("style")) {
+		i := strings.IndexByte(decl, ':')
+		if i == -1 {
+			continue
+		}
+		prop := strings.ToLower(strings.TrimSpace(decl[:i]))
+		value := strings.TrimSpace(decl[i+1:])
+		if j := strings.LastIndex(strings.ToLower(value), "!important"); j != -1 && strings.TrimSpace(value[j+len("!important"):]) == "" {
+			value = strings.TrimSpace(value[:j])
+		}
+		if prop != "" {
+			style[prop] = value // 后面的声明覆盖前面的
+		}
+	}
+	return style
+}
+
+// splitStyle 按 ; 分割声明, 忽略引号和括号里面的 ; . eg: background:url(data:image/png;base64,...)
+func splitStyle(s string) []string {
+	var decls []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+		case c == ';' && depth == 0:
+			decls = append(decls, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(decls, s[start:])
+}
+
+// IsHidden the node or one of its ancestors is hidden by hidden attribute, aria-hidden="true", display:none,
+// visibility:hidden, input[type=hidden], template noscript script style, or the classes in HiddenClasses
+func (n *Node) IsHidden() bool {
+	for p := n; p != nil; p = p.GetParent() {
+		if p.Type == AttributeNode {
+			continue
+		}
+		if p.hidden() {
+			return true
+		}
+	}
+	return false
+}
+
+// hidden 只判断元素自己
+func (n *Node) hidden() bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if hiddenTags[n.Data] || n.hasAttr("hidden") || strings.EqualFold(n.getAttr("aria-hidden"), "true") {
+		return true
+	}
+	if n.Data == "input" && strings.EqualFold(n.getAttr("type"), "hidden") {
+		return true
+	}
+	for _, class := range strings.Fields(n.getAttr("class")) {
+		if HiddenClasses[class] {
+			return true
+		}
+	}
+	if n.hasAttr("style") {
+		style := n.Style()
+		if strings.EqualFold(style["display"], "none") || strings.EqualFold(style["visibility"], "hidden") {
+			return true
+		}
+	}
+	return false
+}
+
+// VisibleText like InnerText but the hidden descendants(see IsHidden) are skipped. empty if the node itself is hidden.
+// eg: mth:"VisibleText"
+func (n *Node) VisibleText() string {
+	if n.Type == AttributeNode {
+		return n.InnerText()
+	}
+	if n.IsHidden() {
+		return ""
+	}
+	var output func(*bytes.Buffer, *html.Node)
+	output = func(buf *bytes.Buffer, n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			buf.WriteString(n.Data)
+			return
+		case html.CommentNode:
+			return
+		case html.ElementNode:
+			if (*Node)(n).hidden() {
+				return
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			output(buf, child)
+		}
+	}
+
+	var buf bytes.Buffer
+	output(&buf, (*html.Node)(n))
+	return buf.String()
+}
@@ -0,0 +1,55 @@
+package htmlquery
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStyle(t *testing.T) {
+	doc := loadHTML(`<div style="Display: none !important; color:red;background:url(data:image/png;base64,AA==); content:';'; color: blue"></div>`)
+	style := doc.FindOne("//div").Style()
+	if fmt.Sprint(style) != "map[background:url(data:image/png;base64,AA==) color:blue content:';' display:none]" {
+		t.Error(style)
+	}
+	if len(doc.FindOne("//body").Style()) != 0 {
+		t.Error("body has no style")
+	}
+}
+
+func TestVisibleText(t *testing.T) {
+	doc := loadHTML(`<html><body><div id="price">
+		<span>$10</span>
+		<span hidden>$12</span>
+		<span aria-hidden="true">$13</span>
+		<span style="display: none">$14</span>
+		<span style="visibility:hidden">$15</span>
+		<span class="price sr-only">ten dollars</span>
+		<template>$16</template>
+		<noscript>$17</noscript>
+		<!-- $18 -->
+		<b class="total">.00</b>
+	</div><p class="d-none"><i>hidden</i></p></body></html>`)
+
+	price := doc.FindOne("//div[@id='price']")
+	if got := strings.Join(strings.Fields(price.VisibleText()), ""); got != "$10.00" {
+		t.Error(got)
+	}
+	if !strings.Contains(price.InnerText(), "$12") {
+		t.Error("InnerText includes the hidden text")
+	}
+
+	i := doc.FindOne("//i")
+	if !i.IsHidden() || i.VisibleText() != "" {
+		t.Error("the ancestor is hidden")
+	}
+	if doc.FindOne("//b").IsHidden() {
+		t.Error("b is visible")
+	}
+
+	HiddenClasses["total"] = true
+	defer delete(HiddenClasses, "total")
+	if !doc.FindOne("//b").IsHidden() || strings.Contains(price.VisibleText(), ".00") {
+		t.Error("total is hidden class")
+	}
+}
@@ -20,7 +20,7 @@ func (n *Node) RegexpEx(exp string) [][]string {
 }
 
 func (n *Node) AttributeValue(key string) (string, error) {
-	if n.Type == html.ElementNode && n.Parent == nil && key == n.Data {
+	if n.Type == AttributeNode && key == n.Data {
 		return n.InnerText(), nil
 	}
 	if attr := n.GetAttributeByKey(key); attr != nil {
@@ -30,18 +30,18 @@ func (n *Node) AttributeValue(key string) (string, error) {
 }
 
 func (n *Node) GetAttributeByValue(val string) *Attribute {
-	for _, attr := range n.Attr {
-		if attr.Val == val {
-			return (*Attribute)(&attr)
+	for i := range n.Attr {
+		if n.Attr[i].Val == val {
+			return (*Attribute)(&n.Attr[i])
 		}
 	}
 	return nil
 }
 
 func (n *Node) GetAttributeByNamespace(namespace string) *Attribute {
-	for _, attr := range n.Attr {
-		if attr.Namespace == namespace {
-			return (*Attribute)(&attr)
+	for i := range n.Attr {
+		if n.Attr[i].Namespace == namespace {
+			return (*Attribute)(&n.Attr[i])
 		}
 	}
 	return nil
@@ -59,11 +59,12 @@ func (n *Node) Attributes() []*Attribute {
 	return result
 }
 
+// GetAttributeByKey the first attribute of the key. the result points to n.Attr, setting it changes the document
+// (until the attributes are added or removed). GetAttributeByValue GetAttributeByNamespace Attributes are the same
 func (n *Node) GetAttributeByKey(key string) *Attribute {
-
-	for _, attr := range n.Attr {
-		if attr.Key == key {
-			return (*Attribute)(&attr)
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			return (*Attribute)(&n.Attr[i])
 		}
 	}
 	return nil
@@ -106,6 +107,20 @@ func (n *Node) GetParent() *Node {
 	return (*Node)(n.Parent)
 }
 
+// Clone deep copy of the node and its descendants. the copy has no parent and siblings
+func (n *Node) Clone() *Node {
+	return (*Node)(cloneNode((*html.Node)(n)))
+}
+
+func cloneNode(n *html.Node) *html.Node {
+	c := &html.Node{Type: n.Type, DataAtom: n.DataAtom, Data: n.Data, Namespace: n.Namespace}
+	c.Attr = append([]html.Attribute(nil), n.Attr...)
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.AppendChild(cloneNode(child))
+	}
+	return c
+}
+
 func (n *Node) Text() string {
 	return n.InnerText()
 }
@@ -137,13 +152,16 @@ func (n *Node) FindOne(expr string) *Node {
 
 // QueryAll searches the html.Node that matches by the specified XPath expr.
 // Return an error if the expression `expr` cannot be parsed.
+// The expression can call the builtin functions. see NewFunctions()
 func (n *Node) QueryAll(expr string) ([]*Node, error) {
-	exp, err := getQuery(expr)
-	if err != nil {
-		return nil, err
-	}
-	nodes := n.QuerySelectorAll(exp)
-	return nodes, nil
+	return defaultFunctions.QueryAll(n, expr)
+}
+
+// QueryAllWithVars is like QueryAll, and binds the variables of the expression.
+// the values are bound as xpath number(int float...), boolean(bool) or string(others),
+// so quotes in values can not break the expression. eg: //a[@data-id=$id]
+func (n *Node) QueryAllWithVars(expr string, vars map[string]interface{}) ([]*Node, error) {
+	return defaultFunctions.QueryAllWithVars(n, expr, vars)
 }
 
 // Query searches the html.Node that matches by the specified XPath expr,
@@ -151,11 +169,7 @@ func (n *Node) QueryAll(expr string) ([]*Node, error) {
 //
 // Return an error if the expression `expr` cannot be parsed.
 func (n *Node) Query(expr string) (*Node, error) {
-	exp, err := getQuery(expr)
-	if err != nil {
-		return nil, err
-	}
-	return n.QuerySelector(exp), nil
+	return defaultFunctions.Query(n, expr)
 }
 
 // QuerySelector returns the first matched html.Node by the specified XPath selector.
@@ -169,34 +183,42 @@ func (n *Node) QuerySelector(selector *xpath.Expr) *Node {
 
 // QuerySelectorAll searches all of the html.Node that matches the specified XPath selectors.
 func (n *Node) QuerySelectorAll(selector *xpath.Expr) []*Node {
+	return selectNodes(selector.Select(n.CreateXPathNavigator()))
+}
+
+// Evaluate evaluates the XPath expr with n as the context node.
+// The result is one of float64, string, bool (count() sum() string() boolean() ...)
+// or []*Node if expr is a node-set expression.
+//
+// Return an error if the expression `expr` cannot be parsed.
+func (n *Node) Evaluate(expr string) (interface{}, error) {
+	return defaultFunctions.Evaluate(n, expr)
+}
+
+// EvaluateSelector evaluates the compiled XPath selector with n as the context node. See `Evaluate()` function.
+func (n *Node) EvaluateSelector(selector *xpath.Expr) interface{} {
+	return evaluateNavigator(selector, n.CreateXPathNavigator())
+}
+
+// selectNodes the result is in document order without duplicate nodes
+func selectNodes(t *xpath.NodeIterator) []*Node {
 	var elems []*Node
-	t := selector.Select(n.CreateXPathNavigator())
 	for t.MoveNext() {
 		nav := t.Current().(*NodeNavigator)
-		n := getCurrentNode(nav)
-		// avoid adding duplicate nodes.
-		if len(elems) > 0 && (elems[0] == n || (nav.NodeType() == xpath.AttributeNode &&
-			nav.LocalName() == elems[0].Data && nav.Value() == elems[0].InnerText())) {
+		if nav.isVirtualAttr() { // @* 不返回扩展函数的虚拟属性
 			continue
 		}
-		elems = append(elems, n)
+		elems = append(elems, getCurrentNode(nav))
 	}
-	return elems
+	return DocumentOrder(elems)
 }
 
-// OutputHTML returns the text including tags name.
+// OutputHTML returns the text including tags name. self is OuterHTML, otherwise InnerHTML
 func (n *Node) OutputHTML(self bool) string {
-	var buf bytes.Buffer
-
-	hn := (*html.Node)(n)
 	if self {
-		html.Render(&buf, hn)
-	} else {
-		for n := n.FirstChild; n != nil; n = n.NextSibling {
-			html.Render(&buf, hn)
-		}
+		return n.OuterHTML()
 	}
-	return buf.String()
+	return n.InnerHTML()
 }
 
 func (top *Node) InnerText() string {
@@ -221,7 +243,12 @@ func (top *Node) InnerText() string {
 }
 
 // CreateXPathNavigator creates a new xpath.NodeNavigator for the specified html.Node.
+// the navigator of the attribute result is on the attribute of its owner element. if the owner has no the attribute
+// any more(eg: removed by RemoveAttr), the navigator is on the detached attribute node, which has no parent and children.
 func (top *Node) CreateXPathNavigator() *NodeNavigator {
+	if i := top.Position(); top.Type == AttributeNode && i != -1 {
+		return &NodeNavigator{curr: top.Parent, root: top.Parent, attr: i, ctxAttr: -1}
+	}
 	n := (*html.Node)(top)
-	return &NodeNavigator{curr: n, root: n, attr: -1}
+	return &NodeNavigator{curr: n, root: n, attr: -1, ctxAttr: -1}
 }
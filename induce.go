@@ -0,0 +1,220 @@
+package extractor
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/474420502/extractor/htmlquery"
+)
+
+// Sample the sample page and the expected values of the fields for InduceSchema.
+// one value is the single field, several values are the slice field(all the values in document order)
+type Sample struct {
+	Extractor *HmtlExtractor
+	Values    map[string][]string // field name -> expected values
+}
+
+// FieldSchema the field of the induced schema
+type FieldSchema struct {
+	Name string `json:"name"`
+	Exp  string `json:"exp"`
+	List bool   `json:"list,omitempty"`
+}
+
+// Schema the schema induced from the sample pages. it can be saved as json(schema file) or rendered as go struct with tags
+type Schema struct {
+	Fields []*FieldSchema `json:"fields"`
+}
+
+// InduceSchema induces the xpaths of the fields which generalize across the samples.
+// the schema is verified by the tag engine, it reproduces the expected values on all the samples.
+func InduceSchema(samples ...*Sample) (*Schema, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("extractor: no sample")
+	}
+
+	var names []string
+	for name := range samples[0].Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	schema := &Schema{}
+	for _, name := range names {
+		field, err := induceField(name, samples)
+		if err != nil {
+			return nil, err
+		}
+		schema.Fields = append(schema.Fields, field)
+	}
+
+	for i, sample := range samples {
+		if !schema.matches(sample) {
+			return nil, fmt.Errorf("extractor: schema can not reproduce the values of sample %d", i)
+		}
+	}
+	return schema, nil
+}
+
+// induceField 所有样本的候选表达式, 按出现的样本数和分数排序, 返回第一个通过验证的
+func induceField(name string, samples []*Sample) (*FieldSchema, error) {
+	list := false
+	for _, sample := range samples {
+		values, ok := sample.Values[name]
+		if !ok || len(values) == 0 {
+			return nil, fmt.Errorf("extractor: sample has no value of field %s", name)
+		}
+		if len(values) > 1 {
+			list = true
+		}
+	}
+
+	var exps []string
+	scores := make(map[string]float64)
+	for _, sample := range samples {
+		etor, values := sample.Extractor, sample.Values[name]
+
+		var suggestions []*Suggestion
+		if list {
+			suggestions = etor.SuggestListXPath(values...)
+		} else {
+			suggestions = etor.SuggestXPath(values[0])
+			// 位置路径在样本之间更容易通用
+			for _, m := range etor.matchExample(values[0]) {
+				suggestions = appendSuggestion(suggestions, etor.newSuggestion(m.node.XPath(), m.score))
+			}
+		}
+
+		for _, s := range suggestions {
+			if _, ok := scores[s.XPath]; !ok {
+				exps = append(exps, s.XPath)
+			}
+			scores[s.XPath] += 1 + s.Score
+		}
+	}
+	sort.SliceStable(exps, func(i, j int) bool {
+		return scores[exps[i]] > scores[exps[j]]
+	})
+
+	for _, exp := range exps {
+		field := &FieldSchema{Name: name, Exp: exp, List: list}
+		schema := &Schema{Fields: []*FieldSchema{field}}
+		verified := true
+		for _, sample := range samples {
+			if !schema.matches(sample) {
+				verified = false
+				break
+			}
+		}
+		if verified {
+			return field, nil
+		}
+	}
+	return nil, fmt.Errorf("extractor: can not induce the xpath of field %s", name)
+}
+
+// structType the struct type for the tag engine. field name is F0 F1 ...
+func (schema *Schema) structType() reflect.Type {
+	var fields []reflect.StructField
+	for i, f := range schema.Fields {
+		typ := reflect.TypeOf("")
+		if f.List {
+			typ = reflect.TypeOf([]string{})
+		}
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("F%d", i),
+			Type: typ,
+			Tag:  reflect.StructTag(fmt.Sprintf("exp:%q", f.Exp)),
+		})
+	}
+	return reflect.StructOf(fields)
+}
+
+// Extract extracts the fields by the schema. string for the single field, []string for the slice field
+func (schema *Schema) Extract(etor *HmtlExtractor) map[string]interface{} {
+	obj := reflect.New(schema.structType())
+	etor.GetObjectByTag(obj.Interface())
+
+	result := make(map[string]interface{})
+	for i, f := range schema.Fields {
+		result[f.Name] = obj.Elem().Field(i).Interface()
+	}
+	return result
+}
+
+// matches 空白规范化后和样本的值一致
+func (schema *Schema) matches(sample *Sample) (ok bool) {
+	defer func() {
+		if err := recover(); err != nil {
+			ok = false
+		}
+	}()
+
+	result := schema.Extract(sample.Extractor)
+	for _, f := range schema.Fields {
+		var got []string
+		switch v := result[f.Name].(type) {
+		case string:
+			got = []string{v}
+		case []string:
+			got = v
+		}
+
+		want := sample.Values[f.Name]
+		if len(got) != len(want) {
+			return false
+		}
+		for i := range got {
+			if htmlquery.NormalizeSpace(got[i]) != htmlquery.NormalizeSpace(want[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Struct the go source of the struct with exp tags. eg:
+//
+//	type Product struct {
+//		Title string `exp:"//h1"`
+//	}
+func (schema *Schema) Struct(name string) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "type %s struct {\n", exportedName(name))
+	for _, f := range schema.Fields {
+		typ := "string"
+		if f.List {
+			typ = "[]string"
+		}
+		fmt.Fprintf(&buf, "%s %s `exp:%q`\n", exportedName(f.Name), typ, f.Exp)
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return buf.String()
+	}
+	return string(src)
+}
+
+// exportedName eg: product-title -> ProductTitle
+func exportedName(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+	s := sb.String()
+	if s == "" || !unicode.IsUpper([]rune(s)[0]) {
+		s = "F" + s
+	}
+	return s
+}
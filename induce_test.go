@@ -0,0 +1,85 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestInduceSchema(t *testing.T) {
+	red := ExtractHtmlString(`<html><body>
+		<div class="header"><a href="/">Shop</a></div>
+		<div class="product">
+			<h2>Red Shoes</h2>
+			<p><span>Price:</span> <b>$10</b></p>
+			<ul class="tags"><li>shoes</li><li>red</li></ul>
+		</div>
+	</body></html>`)
+	blue := ExtractHtmlString(`<html><body>
+		<div class="header"><a href="/">Shop</a></div>
+		<div class="product">
+			<h2>Blue Hat</h2>
+			<p><span>Price:</span> <b>$25</b></p>
+			<ul class="tags"><li>hat</li><li>blue</li><li>winter</li></ul>
+		</div>
+	</body></html>`)
+	samples := []*Sample{
+		{red, map[string][]string{
+			"title": {"Red Shoes"}, "price": {"$10"}, "tags": {"shoes", "red"},
+		}},
+		{blue, map[string][]string{
+			"title": {"Blue Hat"}, "price": {"$25"}, "tags": {"hat", "blue", "winter"},
+		}},
+	}
+
+	schema, err := InduceSchema(samples...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Fields) != 3 || schema.Fields[0].Name != "price" || !schema.Fields[1].List || schema.Fields[2].List {
+		t.Fatal(schema.Fields)
+	}
+
+	// 新页面
+	page := ExtractHtmlString(`<html><body>
+		<div class="header"><a href="/">Shop</a></div>
+		<div class="product">
+			<h2>Green Bag</h2>
+			<p><span>Price:</span> <b>$7</b></p>
+			<ul class="tags"><li>bag</li></ul>
+		</div>
+	</body></html>`)
+	result := schema.Extract(page)
+	if result["title"] != "Green Bag" || result["price"] != "$7" || fmt.Sprint(result["tags"]) != "[bag]" {
+		t.Error(result)
+	}
+
+	src := schema.Struct("product")
+	if !strings.HasPrefix(src, "type Product struct {") || !strings.Contains(src, "Tags  []string `exp:") {
+		t.Error(src)
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded Schema
+	if err := json.Unmarshal(data, &loaded); err != nil || fmt.Sprint(loaded.Extract(page)) != fmt.Sprint(result) {
+		t.Error(string(data), err)
+	}
+
+	// 无法还原的值
+	samples[1].Values["title"] = []string{"Not Exists"}
+	if _, err := InduceSchema(samples...); err == nil {
+		t.Error("should be error")
+	}
+}
+
+func TestExportedName(t *testing.T) {
+	for name, want := range map[string]string{"title": "Title", "product-title": "ProductTitle", "1st": "F1st", "": "F"} {
+		if got := exportedName(name); got != want {
+			t.Error(name, got, want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package extractor
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// FieldChangeType the type of the field change
+type FieldChangeType string
+
+const (
+	// FieldChanged the value of the field is changed
+	FieldChanged FieldChangeType = "changed"
+	// FieldAdded the slice element(or non-nil pointer) is only in the new object
+	FieldAdded FieldChangeType = "added"
+	// FieldRemoved the slice element(or non-nil pointer) is only in the old object
+	FieldRemoved FieldChangeType = "removed"
+)
+
+// FieldChange the change of the field between the old and new extracted object
+type FieldChange struct {
+	Type FieldChangeType `json:"type"`
+	Path string          `json:"path"`          // eg: Price  Items[a1].Price  Items[0]  Tags
+	Exp  string          `json:"exp,omitempty"` // the exp tag of the field
+	Old  interface{}     `json:"old,omitempty"`
+	New  interface{}     `json:"new,omitempty"`
+}
+
+// DiffObjects the field changes from old to new. old and new are the same type of struct, pointer or slice
+// filled by GetObjectByTag or ForEachObjectByTag. eg:
+//
+//	type Item struct {
+//		ID    string `exp:"./@data-id" key:"true"`
+//		Price int    `exp:"./span"`
+//	}
+//
+// the slice elements of struct are matched by the fields with key tag, or by index if no key field.
+// the slice elements of other types are matched by value. the unexported fields and diff:"-" are ignored
+//
+// nil(or nil pointer) old is added, nil new is removed, both nil is no change
+func DiffObjects(old, new interface{}) []*FieldChange {
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(new)
+	if isNilValue(ov) || isNilValue(nv) {
+		switch {
+		case isNilValue(ov) && isNilValue(nv):
+			return nil
+		case isNilValue(ov):
+			return []*FieldChange{{Type: FieldAdded, New: new}}
+		default:
+			return []*FieldChange{{Type: FieldRemoved, Old: old}}
+		}
+	}
+	if ov.Type() != nv.Type() {
+		panic(fmt.Errorf("the types of objects are different: %s, %s", ov.Type(), nv.Type()))
+	}
+	var changes []*FieldChange
+	diffValue(&changes, "", "", ov, nv)
+	return changes
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// isNilValue nil interface, nil pointer
+func isNilValue(v reflect.Value) bool {
+	return !v.IsValid() || v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+func diffValue(changes *[]*FieldChange, path, exp string, ov, nv reflect.Value) {
+	add := func(typ FieldChangeType, o, n interface{}) {
+		*changes = append(*changes, &FieldChange{Type: typ, Path: path, Exp: exp, Old: o, New: n})
+	}
+
+	switch ov.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		switch {
+		case ov.IsNil() && nv.IsNil():
+		case ov.IsNil():
+			add(FieldAdded, nil, nv.Interface())
+		case nv.IsNil():
+			add(FieldRemoved, ov.Interface(), nil)
+		default:
+			diffValue(changes, path, exp, ov.Elem(), nv.Elem())
+		}
+
+	case reflect.Struct:
+		if ov.Type() == timeType {
+			if !ov.Interface().(time.Time).Equal(nv.Interface().(time.Time)) {
+				add(FieldChanged, ov.Interface(), nv.Interface())
+			}
+			return
+		}
+		otype := ov.Type()
+		for i := 0; i < otype.NumField(); i++ {
+			f := otype.Field(i)
+			if f.PkgPath != "" || f.Tag.Get("diff") == "-" {
+				continue
+			}
+			diffValue(changes, joinFieldPath(path, f.Name), f.Tag.Get("exp"), ov.Field(i), nv.Field(i))
+		}
+
+	case reflect.Slice, reflect.Array:
+		etype := ov.Type().Elem()
+		for etype.Kind() == reflect.Ptr {
+			etype = etype.Elem()
+		}
+		switch {
+		case etype.Kind() == reflect.Struct && etype != timeType && len(keyFields(etype)) > 0:
+			diffKeyedSlice(changes, path, exp, ov, nv, keyFields(etype))
+		case etype.Kind() == reflect.Struct && etype != timeType:
+			for i := 0; i < ov.Len() || i < nv.Len(); i++ {
+				epath := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= nv.Len():
+					*changes = append(*changes, &FieldChange{Type: FieldRemoved, Path: epath, Exp: exp, Old: ov.Index(i).Interface()})
+				case i >= ov.Len():
+					*changes = append(*changes, &FieldChange{Type: FieldAdded, Path: epath, Exp: exp, New: nv.Index(i).Interface()})
+				default:
+					diffValue(changes, epath, exp, ov.Index(i), nv.Index(i))
+				}
+			}
+		default:
+			diffValueSlice(changes, path, exp, ov, nv)
+		}
+
+	default:
+		if !reflect.DeepEqual(ov.Interface(), nv.Interface()) {
+			add(FieldChanged, ov.Interface(), nv.Interface())
+		}
+	}
+}
+
+// keyFields the indexes of the fields with key tag. eg: key:"true"
+func keyFields(t reflect.Type) []int {
+	var keys []int
+	for i := 0; i < t.NumField(); i++ {
+		if v, ok := t.Field(i).Tag.Lookup("key"); ok && v != "false" {
+			keys = append(keys, i)
+		}
+	}
+	return keys
+}
+
+// diffKeyedSlice 按key字段对齐元素. eg: Items[a1].Price
+func diffKeyedSlice(changes *[]*FieldChange, path, exp string, ov, nv reflect.Value, keys []int) {
+	keyOf := func(v reflect.Value) (string, bool) {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return "", false
+			}
+			v = v.Elem()
+		}
+		var parts []string
+		for _, i := range keys {
+			parts = append(parts, fmt.Sprint(v.Field(i).Interface()))
+		}
+		return strings.Join(parts, ","), true
+	}
+
+	news := make(map[string]reflect.Value)
+	for i := 0; i < nv.Len(); i++ {
+		if key, ok := keyOf(nv.Index(i)); ok {
+			if _, exists := news[key]; !exists {
+				news[key] = nv.Index(i)
+			}
+		}
+	}
+
+	matched := make(map[string]bool)
+	for i := 0; i < ov.Len(); i++ {
+		key, ok := keyOf(ov.Index(i))
+		if !ok || matched[key] {
+			continue
+		}
+		matched[key] = true
+		epath := path + "[" + key + "]"
+		if n, ok := news[key]; ok {
+			diffValue(changes, epath, exp, ov.Index(i), n)
+		} else {
+			*changes = append(*changes, &FieldChange{Type: FieldRemoved, Path: epath, Exp: exp, Old: ov.Index(i).Interface()})
+		}
+	}
+	for i := 0; i < nv.Len(); i++ {
+		if key, ok := keyOf(nv.Index(i)); ok && !matched[key] {
+			matched[key] = true
+			*changes = append(*changes, &FieldChange{Type: FieldAdded, Path: path + "[" + key + "]", Exp: exp, New: nv.Index(i).Interface()})
+		}
+	}
+}
+
+// diffValueSlice 按值对齐元素, 重复的值按个数计算
+func diffValueSlice(changes *[]*FieldChange, path, exp string, ov, nv reflect.Value) {
+	used := make([]bool, nv.Len())
+	for i := 0; i < ov.Len(); i++ {
+		found := false
+		for j := 0; j < nv.Len(); j++ {
+			if !used[j] && reflect.DeepEqual(ov.Index(i).Interface(), nv.Index(j).Interface()) {
+				used[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			*changes = append(*changes, &FieldChange{Type: FieldRemoved, Path: path, Exp: exp, Old: ov.Index(i).Interface()})
+		}
+	}
+	for j := 0; j < nv.Len(); j++ {
+		if !used[j] {
+			*changes = append(*changes, &FieldChange{Type: FieldAdded, Path: path, Exp: exp, New: nv.Index(j).Interface()})
+		}
+	}
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
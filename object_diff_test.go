@@ -0,0 +1,94 @@
+package extractor
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type diffItem struct {
+	ID    string `exp:"./@data-id" key:"true"`
+	Name  string `exp:"./b"`
+	Price int    `exp:"./span"`
+}
+
+type diffPage struct {
+	Title string   `exp:"//h1"`
+	Tags  []string `exp:"//ul/li"`
+}
+
+func TestDiffObjects(t *testing.T) {
+	extract := func(content string) (diffPage, []*diffItem) {
+		e := ExtractHtmlString(content)
+		var page diffPage
+		e.GetObjectByTag(&page)
+		var items []*diffItem
+		xp, _ := e.XPath("//div[@data-id]")
+		xp.ForEachObjectByTag(&items)
+		return page, items
+	}
+
+	oldPage, oldItems := extract(`<h1>Shop</h1><ul><li>new</li><li>sale</li></ul>
+		<div data-id="a1"><b>apple</b><span>10</span></div>
+		<div data-id="b2"><b>banana</b><span>20</span></div>
+		<div data-id="c3"><b>cherry</b><span>30</span></div>`)
+	newPage, newItems := extract(`<h1>Shop</h1><ul><li>sale</li><li>hot</li></ul>
+		<div data-id="d4"><b>durian</b><span>40</span></div>
+		<div data-id="c3"><b>cherry</b><span>30</span></div>
+		<div data-id="a1"><b>apple</b><span>12</span></div>`)
+
+	data, err := json.Marshal(DiffObjects(oldPage, newPage))
+	if err != nil || string(data) != `[{"type":"removed","path":"Tags","exp":"//ul/li","old":"new"},{"type":"added","path":"Tags","exp":"//ul/li","new":"hot"}]` {
+		t.Error(string(data), err)
+	}
+
+	data, err = json.Marshal(DiffObjects(oldItems, newItems))
+	if err != nil || string(data) != `[{"type":"changed","path":"[a1].Price","exp":"./span","old":10,"new":12},`+
+		`{"type":"removed","path":"[b2]","old":{"ID":"b2","Name":"banana","Price":20}},`+
+		`{"type":"added","path":"[d4]","new":{"ID":"d4","Name":"durian","Price":40}}]` {
+		t.Error(string(data), err)
+	}
+
+	if changes := DiffObjects(&oldPage, &oldPage); len(changes) != 0 {
+		t.Error(changes)
+	}
+}
+
+func TestDiffObjectsByIndex(t *testing.T) {
+	type row struct {
+		A string
+		B []int
+	}
+	type table struct {
+		Rows []row
+		Next *row
+	}
+	old := table{Rows: []row{{"x", []int{1}}, {"y", nil}}}
+	new := table{Rows: []row{{"x", []int{1, 2}}}, Next: &row{A: "z"}}
+
+	changes := DiffObjects(old, new)
+	if len(changes) != 3 || changes[0].Path != "Rows[0].B" || changes[0].New != 2 ||
+		changes[1].Type != FieldRemoved || changes[1].Path != "Rows[1]" ||
+		changes[2].Type != FieldAdded || changes[2].Path != "Next" {
+		data, _ := json.Marshal(changes)
+		t.Error(string(data))
+	}
+}
+
+func TestDiffObjectsNil(t *testing.T) {
+	type item struct {
+		A string
+	}
+	var nilItem *item
+	if changes := DiffObjects(nil, nil); len(changes) != 0 {
+		t.Error(changes)
+	}
+	if changes := DiffObjects(nilItem, nil); len(changes) != 0 {
+		t.Error(changes)
+	}
+	if changes := DiffObjects(nil, &item{"a"}); len(changes) != 1 || changes[0].Type != FieldAdded || changes[0].New.(*item).A != "a" {
+		t.Error(changes)
+	}
+	if changes := DiffObjects(item{"a"}, nilItem); len(changes) != 1 || changes[0].Type != FieldRemoved || changes[0].Old.(item).A != "a" {
+		t.Error(changes)
+	}
+}
@@ -0,0 +1,161 @@
+package extractor
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/474420502/extractor/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// Preprocessor a step which mutates the document before querying. the steps are values, can be composed by Pipeline
+// and reused by the extractors of different sites. eg: RemoveXPath("//script", "//style")
+// funcs is the xpath functions of the extractor, nil is the builtin functions.
+type Preprocessor func(doc *htmlquery.Node, funcs *htmlquery.Functions) error
+
+// DefaultCleanup removes script style noscript, the comments and the tracking pixels(1x1 images)
+var DefaultCleanup = Pipeline(
+	RemoveXPath("//script", "//style", "//noscript", "//comment()"),
+	RemoveXPath("//img[(@width='0' or @width='1') and (@height='0' or @height='1')]"),
+)
+
+// ExtractHtmlWith extractor xml(html), the steps are run on the document before any query. returns the parse error
+// eg: ExtractHtmlWith(content, DefaultCleanup, RenameAttr("data-src", "src"))
+func ExtractHtmlWith(content []byte, steps ...Preprocessor) (*HmtlExtractor, error) {
+	doc, err := htmlquery.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	etor := &HmtlExtractor{content: content, doc: doc, funcs: htmlquery.NewFunctions()}
+	if err := etor.Preprocess(steps...); err != nil {
+		return nil, err
+	}
+	return etor, nil
+}
+
+// Preprocess runs the steps on the document in order, stops at the first error. call it before any query,
+// the results of the previous queries may be detached from the document. RegexpBytes RegexpString use the original content.
+// the xpath of the steps can call the functions registered by RegisterFunction.
+// eg: etor.Preprocess(DefaultCleanup, RemoveXPath("//div[@id='cookie-banner']"), RenameAttr("data-src", "src"))
+func (etor *HmtlExtractor) Preprocess(steps ...Preprocessor) error {
+	return Pipeline(steps...)(etor.doc, etor.funcs)
+}
+
+// Pipeline composes the steps to one step, executed in order. stops at the first error
+func Pipeline(steps ...Preprocessor) Preprocessor {
+	return func(doc *htmlquery.Node, funcs *htmlquery.Functions) error {
+		for _, step := range steps {
+			if step == nil {
+				continue
+			}
+			if err := step(doc, funcs); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// RemoveXPath removes the nodes selected by the xpath expressions(with their descendants).
+// the attribute results are removed from their owner. eg: RemoveXPath("//script", "//@onclick")
+func RemoveXPath(exps ...string) Preprocessor {
+	return func(doc *htmlquery.Node, funcs *htmlquery.Functions) error {
+		for _, exp := range exps {
+			nodes, err := funcs.QueryAll(doc, exp)
+			if err != nil {
+				return err
+			}
+			for _, n := range nodes {
+				n.Remove()
+			}
+		}
+		return nil
+	}
+}
+
+// Unwrap replaces the elements selected by the xpath expressions with their child nodes. eg: Unwrap("//font", "//span[not(@*)]")
+func Unwrap(exps ...string) Preprocessor {
+	return func(doc *htmlquery.Node, funcs *htmlquery.Functions) error {
+		for _, exp := range exps {
+			nodes, err := funcs.QueryAll(doc, exp)
+			if err != nil {
+				return err
+			}
+			for _, n := range nodes {
+				if n.Type != html.ElementNode {
+					continue
+				}
+				var children []*htmlquery.Node
+				for c := n.First(); c != nil; c = c.Next() {
+					children = append(children, c)
+				}
+				n.ReplaceWith(children...)
+			}
+		}
+		return nil
+	}
+}
+
+// RenameAttr renames the attribute from to the attribute to of all elements. the value of to is replaced if exists.
+// eg: RenameAttr("data-src", "src") for the lazy loading images
+func RenameAttr(from, to string) Preprocessor {
+	return func(doc *htmlquery.Node, _ *htmlquery.Functions) error {
+		var walk func(n *html.Node)
+		walk = func(n *html.Node) {
+			if n.Type == html.ElementNode && from != to && (*htmlquery.Node)(n).GetAttributeByKey(from) != nil {
+				attrs := n.Attr[:0]
+				for _, attr := range n.Attr {
+					switch attr.Key {
+					case to:
+						continue
+					case from:
+						attr.Key = to
+					}
+					attrs = append(attrs, attr)
+				}
+				n.Attr = attrs
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+		walk((*html.Node)(doc))
+		return nil
+	}
+}
+
+// wrapSpaceTags 这些元素里面的空白文本没有意义, 直接删除
+var wrapSpaceTags = map[string]bool{
+	"html": true, "head": true, "body": true, "table": true, "thead": true, "tbody": true, "tfoot": true, "tr": true,
+	"ul": true, "ol": true, "dl": true, "select": true,
+}
+
+// NormalizeWhitespace collapses the whitespace of the text nodes to one space. the whitespace only text nodes
+// in html head body table tr ul ol dl select are removed. the text in pre textarea script style is not changed
+func NormalizeWhitespace() Preprocessor {
+	return func(doc *htmlquery.Node, _ *htmlquery.Functions) error {
+		var walk func(n *html.Node)
+		walk = func(n *html.Node) {
+			for c := n.FirstChild; c != nil; {
+				next := c.NextSibling
+				switch c.Type {
+				case html.TextNode:
+					if strings.TrimSpace(c.Data) == "" && n.Type == html.ElementNode && wrapSpaceTags[n.Data] {
+						n.RemoveChild(c)
+					} else {
+						c.Data = htmlquery.CollapseSpace(c.Data)
+					}
+				case html.ElementNode:
+					switch c.Data {
+					case "pre", "textarea", "script", "style":
+					default:
+						walk(c)
+					}
+				}
+				c = next
+			}
+		}
+		walk((*html.Node)(doc))
+		return nil
+	}
+}
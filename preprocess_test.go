@@ -0,0 +1,102 @@
+package extractor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/474420502/extractor/htmlquery"
+)
+
+const preprocessHTML = `<html><head><style>p{}</style><script>var a = 1</script></head><body>
+	<!-- ad -->
+	<div id="cookie-banner">We use cookies</div>
+	<ul>
+		<li><font>  Red
+			 shoes </font></li>
+		<li><img data-src="/b.png" src="/loading.gif" onclick="track()"></li>
+	</ul>
+	<img src="/pixel.gif" width="1" height="1">
+	<pre>a   b</pre>
+</body></html>`
+
+func TestPreprocess(t *testing.T) {
+	e := ExtractHtmlString(preprocessHTML)
+	err := e.Preprocess(
+		DefaultCleanup,
+		RemoveXPath("//*[@id='cookie-banner']", "//@onclick"),
+		Pipeline(Unwrap("//font"), RenameAttr("data-src", "src")),
+		NormalizeWhitespace(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exp := range []string{"//script", "//style", "//comment()", "//*[@id='cookie-banner']", "//font", "//@onclick", "//@data-src", "//img[@width]"} {
+		if n, _ := e.EvaluateInt("count(" + exp + ")"); n != 0 {
+			t.Error(exp, n)
+		}
+	}
+
+	xp, _ := e.XPath("//li")
+	if texts := xp.GetTexts(); fmt.Sprintf("%q", texts) != `[" Red shoes " ""]` {
+		t.Errorf("%q", texts)
+	}
+	if src, _ := e.EvaluateString("string(//li/img/@src)"); src != "/b.png" {
+		t.Error(src)
+	}
+	if pre, _ := e.EvaluateString("string(//pre)"); pre != "a   b" {
+		t.Error(pre)
+	}
+	if n, _ := e.EvaluateInt("count(//ul/text())"); n != 0 {
+		t.Error("whitespace in ul", n)
+	}
+	if html := e.doc.OutputHTML(true); strings.Contains(html, "\n") {
+		t.Error(html)
+	}
+}
+
+func TestPreprocessError(t *testing.T) {
+	e := ExtractHtmlString(preprocessHTML)
+	stop := errors.New("stop")
+	called := false
+	err := e.Preprocess(func(doc *htmlquery.Node, funcs *htmlquery.Functions) error {
+		return stop
+	}, func(doc *htmlquery.Node, funcs *htmlquery.Functions) error {
+		called = true
+		return nil
+	})
+	if err != stop || called {
+		t.Error(err, called)
+	}
+	if err := e.Preprocess(RemoveXPath("//div[")); err == nil {
+		t.Error("err should not be nil")
+	}
+}
+
+func TestExtractHtmlWith(t *testing.T) {
+	e, err := ExtractHtmlWith([]byte(preprocessHTML), DefaultCleanup, RenameAttr("data-src", "src"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := e.EvaluateInt("count(//script | //comment() | //@data-src)"); n != 0 {
+		t.Error(n)
+	}
+	if _, err := ExtractHtmlWith([]byte(preprocessHTML), RemoveXPath("//div[")); err == nil {
+		t.Error("err should not be nil")
+	}
+
+	// 步骤的xpath可以调用提取器注册的函数
+	e = ExtractHtmlString(preprocessHTML)
+	e.RegisterFunction("banner", htmlquery.FuncBool, func(ctx *htmlquery.Node, args ...interface{}) interface{} {
+		id, _ := ctx.AttributeValue("id")
+		return strings.HasSuffix(id, "-banner")
+	})
+	if err := e.Preprocess(RemoveXPath("//div[banner()]")); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := e.EvaluateInt("count(//div)"); n != 0 {
+		t.Error(n)
+	}
+}
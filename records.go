@@ -0,0 +1,302 @@
+package extractor
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/474420502/extractor/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// RecordRegion the repeated records with similar structure under the same parent. eg: search results, product grids
+type RecordRegion struct {
+	XPath   string   // the expression selects the records. can be used with ForEachObjectByTag
+	Records *XPath   // the record elements
+	Fields  []string // the common relative xpaths of the fields inside each record. eg: ./h3[1]/a[1] ./a[1]/@href
+	Score   float64  // higher is better
+}
+
+// RecordSimilarity the min structural similarity(0 ~ 1) of the records in the same region
+var RecordSimilarity = 0.5
+
+// DetectRecords finds the repeated sibling subtrees with similar structure, and returns the regions ranked by score
+func (etor *HmtlExtractor) DetectRecords() []*RecordRegion {
+	var regions []*RecordRegion
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode || n.Type == html.DocumentNode {
+			regions = append(regions, etor.detectRegions((*htmlquery.Node)(n))...)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk((*html.Node)(etor.doc))
+
+	sort.SliceStable(regions, func(i, j int) bool {
+		return regions[i].Score > regions[j].Score
+	})
+	return regions
+}
+
+// detectRegions 按标签分组子元素, 和组内大多数结构相似的子元素作为记录
+func (etor *HmtlExtractor) detectRegions(parent *htmlquery.Node) []*RecordRegion {
+	groups := make(map[string][]*htmlquery.Node)
+	var tags []string
+	for c := parent.First(); c != nil; c = c.Next() {
+		if c.Type != html.ElementNode || c.Data == "script" || c.Data == "style" {
+			continue
+		}
+		if _, ok := groups[c.Data]; !ok {
+			tags = append(tags, c.Data)
+		}
+		groups[c.Data] = append(groups[c.Data], c)
+	}
+
+	var regions []*RecordRegion
+	for _, tag := range tags {
+		children := groups[tag]
+		if len(children) < 2 {
+			continue
+		}
+
+		shapes := make([]map[string]bool, len(children))
+		for i, c := range children {
+			shapes[i] = recordShape(c)
+		}
+		groups, shapeIndex := groupShapes(shapes)
+
+		// 和其他记录平均相似度最高的作为代表. 相同结构的记录只比较一次, 结构太多的时候抽样比较
+		samples := sampleShapes(groups, maxShapeSamples)
+		var center *shapeGroup
+		centerSim := -1.0
+		for _, g := range samples {
+			sum := float64(g.count - 1)
+			for _, o := range samples {
+				if o != g {
+					sum += float64(o.count) * shapeSimilarity(g.keys, o.keys)
+				}
+			}
+			if sum > centerSim {
+				center, centerSim = g, sum
+			}
+		}
+		for _, g := range groups {
+			g.sim = shapeSimilarity(g.keys, center.keys)
+		}
+
+		var records []*htmlquery.Node
+		var sim float64
+		var elems, text int
+		for i, c := range children {
+			g := groups[shapeIndex[i]]
+			if g.sim >= RecordSimilarity {
+				records = append(records, c)
+				sim += g.sim
+				elems += len(g.keys)
+				text += len(htmlquery.NormalizeSpace(c.Text()))
+			}
+		}
+		// 至少3个记录, 或者2个结构复杂的记录
+		if len(records) < 2 || len(records) == 2 && elems < 6 || text == 0 {
+			continue
+		}
+
+		count := float64(len(records))
+		region := &RecordRegion{
+			XPath:   etor.recordsXPath(parent, records),
+			Records: newXPath(records...),
+			Fields:  recordFields(records),
+			Score:   sim / count * math.Log2(count+1) * (float64(elems)/count + float64(text)/count/20),
+		}
+		region.Records.funcs = etor.funcs
+		regions = append(regions, region)
+	}
+	return regions
+}
+
+// recordShape 记录的结构: 去掉位置的相对标签路径的集合. eg: h3 h3/a span
+func recordShape(record *htmlquery.Node) map[string]bool {
+	shape := make(map[string]bool)
+	var walk func(n *html.Node, path string)
+	walk = func(n *html.Node, path string) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode {
+				p := path + "/" + c.Data
+				shape[p] = true
+				walk(c, p)
+			}
+		}
+	}
+	walk((*html.Node)(record), ".")
+	if len(shape) == 0 {
+		shape["."] = true
+	}
+	return shape
+}
+
+// maxShapeSamples 选择代表记录时最多比较的不同结构数量
+var maxShapeSamples = 64
+
+// shapeGroup 结构相同的记录
+type shapeGroup struct {
+	keys  []string // 排序的结构路径
+	count int
+	sim   float64 // 和代表记录的相似度
+}
+
+// groupShapes 合并相同的结构, 按第一次出现的顺序. index 是每个记录所在的组
+func groupShapes(shapes []map[string]bool) (groups []*shapeGroup, index []int) {
+	seen := make(map[string]int)
+	index = make([]int, len(shapes))
+	for i, shape := range shapes {
+		keys := make([]string, 0, len(shape))
+		for k := range shape {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		id := strings.Join(keys, "\x00")
+		g, ok := seen[id]
+		if !ok {
+			g = len(groups)
+			seen[id] = g
+			groups = append(groups, &shapeGroup{keys: keys})
+		}
+		groups[g].count++
+		index[i] = g
+	}
+	return groups, index
+}
+
+// sampleShapes 超过 max 的时候等间隔抽样
+func sampleShapes(groups []*shapeGroup, max int) []*shapeGroup {
+	if len(groups) <= max {
+		return groups
+	}
+	samples := make([]*shapeGroup, max)
+	for i := range samples {
+		samples[i] = groups[i*len(groups)/max]
+	}
+	return samples
+}
+
+// shapeSimilarity 两个排序的集合的 jaccard 相似度
+func shapeSimilarity(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	var inter int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			inter++
+			i, j = i+1, j+1
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+// recordsXPath 选择所有记录的表达式, 优先使用共同的class
+func (etor *HmtlExtractor) recordsXPath(parent *htmlquery.Node, records []*htmlquery.Node) string {
+	base := parent.RobustXPath()
+	if base == "/" {
+		base = ""
+	}
+	tag := records[0].Data
+
+	var exps []string
+	for _, class := range strings.Fields(attrValue(records[0], "class")) {
+		all := true
+		for _, r := range records[1:] {
+			if !hasToken(attrValue(r, "class"), class) {
+				all = false
+				break
+			}
+		}
+		if all {
+			exps = append(exps, base+"/"+tag+"[contains(concat(' ', normalize-space(@class), ' '), "+htmlquery.XPathLiteral(" "+class+" ")+")]")
+		}
+	}
+	exps = append(exps, base+"/"+tag)
+
+	want := newXPath(records...)
+	for _, exp := range exps {
+		if nodes, err := etor.funcs.QueryAll(etor.doc, exp); err == nil {
+			if got := newXPath(nodes...); got.Contains(want) && want.Contains(got) {
+				return exp
+			}
+		}
+	}
+
+	var paths []string
+	for _, r := range records {
+		paths = append(paths, r.XPath())
+	}
+	return strings.Join(paths, " | ")
+}
+
+// recordFields 至少一半的记录都有的, 有文本的元素和链接图片属性的相对路径
+func recordFields(records []*htmlquery.Node) []string {
+	var fields []string
+	counts := make(map[string]int)
+	for _, r := range records {
+		prefix := r.XPath()
+		seen := make(map[string]bool)
+		add := func(path string) {
+			if seen[path] {
+				return
+			}
+			seen[path] = true
+			if counts[path] == 0 {
+				fields = append(fields, path)
+			}
+			counts[path]++
+		}
+
+		var walk func(n *html.Node)
+		walk = func(n *html.Node) {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type != html.ElementNode {
+					continue
+				}
+				node := (*htmlquery.Node)(c)
+				rel := "." + strings.TrimPrefix(node.XPath(), prefix)
+				if hasOwnText(c) {
+					add(rel)
+				}
+				for _, key := range []string{"href", "src"} {
+					if attrValue(node, key) != "" {
+						add(rel + "/@" + key)
+					}
+				}
+				walk(c)
+			}
+		}
+		if hasOwnText((*html.Node)(r)) {
+			add(".")
+		}
+		walk((*html.Node)(r))
+	}
+
+	var common []string
+	for _, f := range fields {
+		if counts[f]*2 >= len(records) {
+			common = append(common, f)
+		}
+	}
+	return common
+}
+
+func hasOwnText(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode && strings.TrimSpace(c.Data) != "" {
+			return true
+		}
+	}
+	return false
+}
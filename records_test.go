@@ -0,0 +1,81 @@
+package extractor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDetectRecords(t *testing.T) {
+	e := ExtractHtmlString(`<html><body>
+		<ul class="nav"><li><a href="/">Home</a></li><li><a href="/about">About</a></li><li><a href="/help">Help</a></li></ul>
+		<div id="results">
+			<div class="result"><h3><a href="/p/1">First result</a></h3><p class="desc">the description of the first result</p><span>2021</span></div>
+			<div class="result ad"><h3><a href="/p/2">Second result</a></h3><p class="desc">the description of the second result</p></div>
+			<div class="result"><h3><a href="/p/3">Third result</a></h3><p class="desc">the description of the third result</p><span>2023</span></div>
+			<div class="more">more</div>
+		</div>
+	</body></html>`)
+
+	regions := e.DetectRecords()
+	if len(regions) < 2 {
+		t.Fatal(regions)
+	}
+
+	r := regions[0]
+	if r.XPath != "//div[@id='results']/div[contains(concat(' ', normalize-space(@class), ' '), ' result ')]" || r.Records.Len() != 3 {
+		t.Fatal(r.XPath, r.Records.Len())
+	}
+	if fmt.Sprint(r.Fields) != "[./h3[1]/a[1] ./h3[1]/a[1]/@href ./p[1] ./span[1]]" {
+		t.Error(r.Fields)
+	}
+
+	type result struct {
+		Title string `exp:"./h3[1]/a[1]"`
+		Link  string `exp:"./h3[1]/a[1]/@href"`
+	}
+	var results []result
+	xp, err := e.XPath(r.XPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xp.ForEachObjectByTag(&results)
+	if len(results) != 3 || results[1].Title != "Second result" || results[2].Link != "/p/3" {
+		t.Error(results)
+	}
+
+	if regions[1].XPath != "//ul/li" || regions[1].Records.Len() != 3 {
+		t.Error(regions[1].XPath)
+	}
+}
+
+func TestDetectRecordsShapes(t *testing.T) {
+	a := []string{"./a", "./h3", "./h3/a"}
+	if s := shapeSimilarity(a, []string{"./h3", "./h3/a", "./p"}); s != 0.5 {
+		t.Error(s)
+	}
+	if s := shapeSimilarity(nil, nil); s != 1 {
+		t.Error(s)
+	}
+
+	shapes := []map[string]bool{{"./a": true}, {"./b": true}, {"./a": true}}
+	groups, index := groupShapes(shapes)
+	if len(groups) != 2 || groups[0].count != 2 || fmt.Sprint(index) != "[0 1 0]" {
+		t.Error(groups, index)
+	}
+	if samples := sampleShapes(append(groups, groups...), 2); len(samples) != 2 || samples[0] != groups[0] || samples[1] != groups[0] {
+		t.Error(samples)
+	}
+
+	// 每个记录的结构都不同, 只比较抽样的结构
+	var sb strings.Builder
+	sb.WriteString("<html><body><ul>")
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&sb, `<li><h3><a href="/p/%d">title %d</a></h3><p>desc</p>%s</li>`, i, i, fmt.Sprintf("<x%d></x%d>", i, i))
+	}
+	sb.WriteString("</ul></body></html>")
+	regions := ExtractHtmlString(sb.String()).DetectRecords()
+	if len(regions) == 0 || regions[0].XPath != "//ul/li" || regions[0].Records.Len() != 300 {
+		t.Error(regions[0].XPath, regions[0].Records.Len())
+	}
+}
@@ -0,0 +1,44 @@
+package extractor
+
+import (
+	"strings"
+
+	"github.com/474420502/extractor/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// ByRole the elements with the ARIA role(explicit or implicit). if name is given, the accessible name must equal name
+// (whitespace normalized, ignoring case). eg: ByRole("button", "Add to cart"). tag: role:"button" name:"Add to cart"
+func (etor *HmtlExtractor) ByRole(role string, name ...string) *XPath {
+	xp := newXPath(findByRole(etor.doc, role, name...)...)
+	xp.funcs = etor.funcs
+	return xp
+}
+
+// ByRole the elements with the ARIA role in the results(include the results themselves)
+func (xp *XPath) ByRole(role string, name ...string) *XPath {
+	var results []*htmlquery.Node
+	for _, n := range xp.results {
+		results = append(results, findByRole(n, role, name...)...)
+	}
+	return xp.derive(results)
+}
+
+func findByRole(root *htmlquery.Node, role string, name ...string) []*htmlquery.Node {
+	role = strings.ToLower(strings.TrimSpace(role))
+	var results []*htmlquery.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			node := (*htmlquery.Node)(n)
+			if node.Role() == role && (len(name) == 0 || strings.EqualFold(node.AccessibleName(), htmlquery.NormalizeSpace(name[0]))) {
+				results = append(results, node)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk((*html.Node)(root))
+	return results
+}
@@ -0,0 +1,73 @@
+package extractor
+
+import (
+	"fmt"
+	"testing"
+)
+
+const roleHTML = `<html><body>
+	<header><nav aria-label="Main"><a href="/">Home</a><a href="/cart">Cart</a></nav></header>
+	<main>
+		<h1>Shoes</h1>
+		<div class="item"><h2>Runner</h2><button data-sku="r1">Add to cart</button><input type="submit" value="Buy now"></div>
+		<div class="item"><h2>Walker</h2><button data-sku="w1" aria-label="Add to  Cart"><i class="icon"></i></button></div>
+		<label for="q">Search</label><input id="q" type="search">
+		<div role="button" data-sku="x1">More</div>
+	</main>
+</body></html>`
+
+func TestByRole(t *testing.T) {
+	e := ExtractHtmlString(roleHTML)
+
+	if got := fmt.Sprint(e.ByRole("button").GetAttrValuesByKey("data-sku")); got != "[r1 w1 x1]" {
+		t.Error(got)
+	}
+	if got := fmt.Sprint(e.ByRole("button", "add to cart").GetAttrValuesByKey("data-sku")); got != "[r1 w1]" {
+		t.Error(got)
+	}
+	if got := fmt.Sprint(e.ByRole("link").GetTexts()); got != "[Home Cart]" {
+		t.Error(got)
+	}
+	if got := e.ByRole("navigation", "Main").Len(); got != 1 {
+		t.Error(got)
+	}
+	if got := e.ByRole("searchbox", "Search").Len(); got != 1 {
+		t.Error(got)
+	}
+	if got := e.ByRole("banner").Len(); got != 1 {
+		t.Error(got)
+	}
+
+	items, err := e.XPath("//div[@class='item']")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(items.ByRole("heading").GetTexts()); got != "[Runner Walker]" {
+		t.Error(got)
+	}
+}
+
+type roleItem struct {
+	Name string   `role:"heading"`
+	SKU  string   `role:"button" name:"Add to cart" mth:"AttrValue,data-sku"`
+	Buy  []string `role:"button" name:"Buy now" mth:"AttrValue,value"`
+}
+
+func TestRoleTag(t *testing.T) {
+	e := ExtractHtmlString(roleHTML)
+	xp, err := e.XPath("//div[@class='item']")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []*roleItem
+	xp.ForEachObjectByTag(&items)
+	if len(items) != 2 {
+		t.Fatal(len(items))
+	}
+	if items[0].Name != "Runner" || items[0].SKU != "r1" || fmt.Sprint(items[0].Buy) != "[Buy now]" {
+		t.Errorf("%#v", items[0])
+	}
+	if items[1].Name != "Walker" || items[1].SKU != "w1" || len(items[1].Buy) != 0 {
+		t.Errorf("%#v", items[1])
+	}
+}
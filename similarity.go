@@ -0,0 +1,212 @@
+package extractor
+
+import (
+	"sort"
+	"strings"
+)
+
+// SimilarText the similarity percent(0 ~ 100) of two strings like php similar_text, compared by runes.
+// eg: mth:"r:SimilarText,expected"
+func SimilarText(one, two string) (percent float64) {
+	a, b := []rune(one), []rune(two)
+	if len(a)+len(b) == 0 {
+		return 0
+	}
+	row := make([]int, len(b)+1)
+	sim := similarRunes(a, b, row)
+	return float64(sim*200) / float64(len(a)+len(b))
+}
+
+// similarRunes 最长公共子串的长度加上左右两边递归的结果. 只切片不复制
+func similarRunes(a, b []rune, row []int) int {
+	pos1, pos2, max := longestCommonSubstring(a, b, row)
+	if max == 0 {
+		return 0
+	}
+	return max + similarRunes(a[:pos1], b[:pos2], row) + similarRunes(a[pos1+max:], b[pos2+max:], row)
+}
+
+// longestCommonSubstring 动态规划 O(len(a)*len(b)), 只用一行. 相同长度取a中位置最前的, 然后是b中最前的(和php一致)
+func longestCommonSubstring(a, b []rune, row []int) (pos1, pos2, max int) {
+	if len(a) == 0 || len(b) == 0 {
+		return 0, 0, 0
+	}
+	row = row[:len(b)+1]
+	for j := range row {
+		row[j] = 0
+	}
+
+	endA, endB := 0, 0
+	for i := 0; i < len(a); i++ {
+		// row[j+1] 是以a[i] b[j]结尾的公共子串长度, 倒序更新不会覆盖上一行需要的值
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] != b[j] {
+				row[j+1] = 0
+				continue
+			}
+			l := row[j] + 1
+			row[j+1] = l
+			if l > max || l == max && i-l == endA-max && j < endB {
+				max, endA, endB = l, i, j
+			}
+		}
+	}
+	if max == 0 {
+		return 0, 0, 0
+	}
+	return endA - max + 1, endB - max + 1, max
+}
+
+// Levenshtein the edit distance(insert delete substitute) of two strings, compared by runes.
+// eg: mth:"r:Levenshtein,expected"
+func Levenshtein(one, two string) int {
+	a, b := []rune(one), []rune(two)
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		prev := row[0] // row[i-1][j-1]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cur := row[j]
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			row[j] = minInt(minInt(row[j]+1, row[j-1]+1), prev+cost)
+			prev = cur
+		}
+	}
+	return row[len(b)]
+}
+
+// JaroWinkler the Jaro-Winkler similarity(0 ~ 1) of two strings, compared by runes. the common prefix(up to 4) is boosted.
+// eg: mth:"r:JaroWinkler,expected"
+func JaroWinkler(one, two string) float64 {
+	a, b := []rune(one), []rune(two)
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	window := maxInt(len(a), len(b))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	matchedA := make([]bool, len(a))
+	matchedB := make([]bool, len(b))
+	matches := 0
+	for i := range a {
+		for j := maxInt(0, i-window); j < minInt(len(b), i+window+1); j++ {
+			if !matchedB[j] && a[i] == b[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// 匹配的字符顺序不同的一半是换位数
+	transpositions, j := 0, 0
+	for i := range a {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < minInt(4, minInt(len(a), len(b))) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// TokenSetRatio the similarity(0 ~ 100) of the word sets of two strings, ignoring the order and duplicates of words.
+// eg: "new york mets" and "mets new york" is 100. mth:"r:TokenSetRatio,expected"
+func TokenSetRatio(one, two string) float64 {
+	setA, setB := tokenSet(one), tokenSet(two)
+	var common, onlyA, onlyB []string
+	for t := range setA {
+		if setB[t] {
+			common = append(common, t)
+		} else {
+			onlyA = append(onlyA, t)
+		}
+	}
+	for t := range setB {
+		if !setA[t] {
+			onlyB = append(onlyB, t)
+		}
+	}
+	sort.Strings(common)
+	sort.Strings(onlyA)
+	sort.Strings(onlyB)
+
+	t0 := strings.Join(common, " ")
+	t1 := strings.TrimSpace(t0 + " " + strings.Join(onlyA, " "))
+	t2 := strings.TrimSpace(t0 + " " + strings.Join(onlyB, " "))
+	if t1 == "" && t2 == "" {
+		return 100
+	}
+
+	ratio := levenshteinRatio(t1, t2)
+	if t0 != "" {
+		if r := levenshteinRatio(t0, t1); r > ratio {
+			ratio = r
+		}
+		if r := levenshteinRatio(t0, t2); r > ratio {
+			ratio = r
+		}
+	}
+	return ratio
+}
+
+func tokenSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, t := range textTokens(s) {
+		set[t] = true
+	}
+	return set
+}
+
+// levenshteinRatio 0 ~ 100
+func levenshteinRatio(a, b string) float64 {
+	l := maxInt(len([]rune(a)), len([]rune(b)))
+	if l == 0 {
+		return 100
+	}
+	return 100 * (1 - float64(Levenshtein(a, b))/float64(l))
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,97 @@
+package extractor
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSimilarText(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		want float64
+	}{
+		{"World", "Word", 88.88888888888889},
+		{"Hello", "World", 20},
+		{"", "", 0},
+		{"abc", "", 0},
+		{"你好世界", "你好", 66.66666666666667}, // 按字符而不是字节
+		{"テキスト", "テキスト", 100},
+	} {
+		if got := SimilarText(c.a, c.b); math.Abs(got-c.want) > 1e-9 {
+			t.Error(c.a, c.b, got, c.want)
+		}
+	}
+
+	// 长文本
+	long := strings.Repeat("the quick brown fox jumps over the lazy dog. ", 100)
+	if got := SimilarText(long, strings.Replace(long, "lazy", "busy", 1)); got < 99 || got >= 100 {
+		t.Error(got)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		want int
+	}{
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+		{"abc", "abc", 0},
+		{"你好世界", "你们世界", 1},
+		{"flaw", "lawn", 2},
+	} {
+		if got := Levenshtein(c.a, c.b); got != c.want {
+			t.Error(c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		want float64
+	}{
+		{"MARTHA", "MARHTA", 0.961},
+		{"DIXON", "DICKSONX", 0.813},
+		{"DWAYNE", "DUANE", 0.840},
+		{"abc", "abc", 1},
+		{"abc", "xyz", 0},
+		{"", "", 1},
+		{"東京都", "東京府", 0.822},
+	} {
+		if got := JaroWinkler(c.a, c.b); math.Abs(got-c.want) > 0.001 {
+			t.Error(c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestTokenSetRatio(t *testing.T) {
+	if r := TokenSetRatio("new york mets", "mets new york"); r != 100 {
+		t.Error(r)
+	}
+	if r := TokenSetRatio("New York Mets vs Atlanta Braves", "Atlanta Braves vs New York Mets"); r != 100 {
+		t.Error(r)
+	}
+	if r := TokenSetRatio("mariners vs angels", "los angeles angels vs seattle mariners"); r != 100 {
+		t.Error(r)
+	}
+	if a, b := TokenSetRatio("apple pie", "apple tart"), TokenSetRatio("apple pie", "banana split"); a <= b || b >= 50 {
+		t.Error(a, b)
+	}
+}
+
+type similarTag struct {
+	Distance int     `exp:"//h1" mth:"r:Levenshtein,kitten"`
+	Percent  float64 `exp:"//h1" mth:"r:SimilarText,sitting"`
+	Ratio    float64 `exp:"//p" mth:"r:TokenSetRatio,world-hello"`
+}
+
+func TestSimilarityTag(t *testing.T) {
+	var v similarTag
+	ExtractHtmlString(`<h1>sitting</h1><p>Hello, World!</p>`).GetObjectByTag(&v)
+	if v.Distance != 3 || v.Percent != 100 || v.Ratio != 100 {
+		t.Error(fmt.Sprintf("%#v", v))
+	}
+}
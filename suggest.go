@@ -0,0 +1,287 @@
+package extractor
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/474420502/extractor/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// Suggestion the candidate xpath inferred from the example values
+type Suggestion struct {
+	XPath  string   // the expression can be pasted into exp tag
+	Score  float64  // 0 ~ 1, higher is better
+	Values []string // the values extracted by XPath
+
+	nodes []*htmlquery.Node
+}
+
+// Tag the struct tag of the suggestion. eg: exp:"//h1"
+func (s *Suggestion) Tag() string {
+	return fmt.Sprintf("exp:%q", s.XPath)
+}
+
+// SuggestSimilarity the min similarity(0 ~ 1) of the node value and the example value when not equal
+var SuggestSimilarity = 0.75
+
+// SuggestXPath finds the nodes whose text or attribute equals or closely matches the example value,
+// and returns the ranked candidate xpaths with the values they extract.
+func (etor *HmtlExtractor) SuggestXPath(example string) []*Suggestion {
+	var suggestions []*Suggestion
+	for _, m := range etor.matchExample(example) {
+		exp := m.node.RobustXPath()
+		suggestions = appendSuggestion(suggestions, etor.newSuggestion(exp, m.score))
+	}
+	return rankSuggestions(suggestions)
+}
+
+// SuggestListXPath like SuggestXPath, but for the slice field. the candidate xpaths select all the nodes of the examples
+// and generalize to the similar nodes. eg: examples of the first and third item select all items
+func (etor *HmtlExtractor) SuggestListXPath(examples ...string) []*Suggestion {
+	if len(examples) == 1 {
+		return etor.SuggestXPath(examples[0])
+	}
+
+	// 每个例子最好的匹配
+	var nodes []*htmlquery.Node
+	var total float64
+	for _, example := range examples {
+		matches := etor.matchExample(example)
+		if len(matches) == 0 {
+			return nil
+		}
+		nodes = append(nodes, matches[0].node)
+		total += matches[0].score
+	}
+	score := total / float64(len(examples))
+
+	var suggestions []*Suggestion
+	for _, exp := range generalizeXPaths(nodes) {
+		s := etor.newSuggestion(exp, score)
+		if s != nil && newXPath(s.nodes...).Contains(newXPath(nodes...)) {
+			suggestions = appendSuggestion(suggestions, s)
+		}
+	}
+	return rankSuggestions(suggestions)
+}
+
+type exampleMatch struct {
+	node  *htmlquery.Node
+	score float64
+}
+
+// matchExample 值等于或者相似于example的最深的节点, 按相似度排序
+func (etor *HmtlExtractor) matchExample(example string) []exampleMatch {
+	example = htmlquery.NormalizeSpace(example)
+	if example == "" {
+		return nil
+	}
+
+	var matches []exampleMatch
+	// 返回子树中最好的元素匹配分数
+	var walk func(n *html.Node) float64
+	walk = func(n *html.Node) float64 {
+		var best float64
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if s := walk(c); s > best {
+				best = s
+			}
+		}
+		if n.Type != html.ElementNode || n.Data == "script" || n.Data == "style" {
+			return best
+		}
+
+		node := (*htmlquery.Node)(n)
+		for i, attr := range n.Attr {
+			if attr.Key == "class" || attr.Key == "style" {
+				continue
+			}
+			if s := matchScore(attr.Val, example); s > 0 {
+				attrs := node.Attributes()
+				an, _ := node.Query("@" + attrs[i].Key)
+				if an != nil {
+					matches = append(matches, exampleMatch{an, s})
+				}
+			}
+		}
+
+		// 子元素匹配得更好的时候, 不使用父元素
+		if s := matchScore(node.InnerText(), example); s > best {
+			matches = append(matches, exampleMatch{node, s})
+			best = s
+		}
+		return best
+	}
+	walk((*html.Node)(etor.doc))
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	return matches
+}
+
+// matchScore 1 is equal, 0 is not match
+func matchScore(value, example string) float64 {
+	value = htmlquery.NormalizeSpace(value)
+	switch {
+	case value == "":
+		return 0
+	case value == example:
+		return 1
+	case strings.EqualFold(value, example):
+		return 0.95
+	case len(value) > 3*len(example)+16: // 太长的文本不比较相似度
+		return 0
+	case strings.Contains(value, example):
+		return 0.9 * float64(len(example)) / float64(len(value))
+	}
+	if sim := SimilarText(value, example) / 100; sim >= SuggestSimilarity {
+		return 0.9 * sim
+	}
+	return 0
+}
+
+func (etor *HmtlExtractor) newSuggestion(exp string, score float64) *Suggestion {
+	nodes, err := etor.funcs.QueryAll(etor.doc, exp)
+	if err != nil || len(nodes) == 0 {
+		return nil
+	}
+	s := &Suggestion{XPath: exp, nodes: nodes}
+	for _, n := range nodes {
+		s.Values = append(s.Values, htmlquery.NormalizeSpace(n.Text()))
+	}
+
+	// 位置索引和长表达式不稳定
+	penalty := 0.02*float64(len(regexpPositional.FindAllString(exp, -1))) + 0.0005*float64(len(exp))
+	s.Score = score - penalty
+	if s.Score < 0 {
+		s.Score = 0
+	}
+	return s
+}
+
+var regexpPositional = regexp.MustCompile(`\[\d+\]`)
+
+// generalizeXPaths 把多个节点的位置路径泛化为选择所有节点的表达式
+func generalizeXPaths(nodes []*htmlquery.Node) []string {
+	// 属性泛化所属的元素
+	attr := ""
+	if nodes[0].IsAttribute() {
+		attr = "/@" + nodes[0].Data
+		var owners []*htmlquery.Node
+		for _, n := range nodes {
+			if !n.IsAttribute() || n.Data != nodes[0].Data {
+				return nil
+			}
+			owners = append(owners, n.GetParent())
+		}
+		nodes = owners
+	}
+
+	var paths [][]string
+	for _, n := range nodes {
+		if !n.IsElement() {
+			return nil
+		}
+		paths = append(paths, strings.Split(strings.TrimPrefix(n.XPath(), "/"), "/"))
+	}
+
+	// 相同的深度和标签才能泛化
+	for _, p := range paths[1:] {
+		if len(p) != len(paths[0]) {
+			return nil
+		}
+	}
+
+	var steps []string
+	common := 0 // 公共祖先的深度
+	for i := range paths[0] {
+		step := paths[0][i]
+		tag := step[:strings.LastIndex(step, "[")]
+		same := true
+		for _, p := range paths[1:] {
+			if p[i][:strings.LastIndex(p[i], "[")] != tag {
+				return nil
+			}
+			if p[i] != step {
+				same = false
+			}
+		}
+		if same {
+			if common == i {
+				common++
+			}
+		} else {
+			step = tag
+		}
+		steps = append(steps, step)
+	}
+
+	var exps []string
+	// 以公共祖先为锚点
+	if common > 0 {
+		ancestor := nodes[0]
+		for i := len(paths[0]); i > common; i-- {
+			ancestor = ancestor.GetParent()
+		}
+		exps = append(exps, ancestor.RobustXPath()+"/"+strings.Join(steps[common:], "/")+attr)
+	}
+	// 最后两层
+	if len(steps) >= 2 {
+		exps = append(exps, "//"+strings.Join(steps[len(steps)-2:], "/")+attr)
+	}
+	// 共同的class
+	tag := steps[len(steps)-1]
+	if !strings.Contains(tag, "[") {
+		for _, class := range strings.Fields(attrValue(nodes[0], "class")) {
+			all := true
+			for _, n := range nodes[1:] {
+				if !hasToken(attrValue(n, "class"), class) {
+					all = false
+					break
+				}
+			}
+			if all {
+				exps = append(exps, "//"+tag+"[contains(concat(' ', normalize-space(@class), ' '), "+htmlquery.XPathLiteral(" "+class+" ")+")]"+attr)
+			}
+		}
+	}
+	exps = append(exps, "/"+strings.Join(steps, "/")+attr)
+	return exps
+}
+
+func appendSuggestion(suggestions []*Suggestion, s *Suggestion) []*Suggestion {
+	if s == nil {
+		return suggestions
+	}
+	for _, o := range suggestions {
+		if o.XPath == s.XPath {
+			return suggestions
+		}
+	}
+	return append(suggestions, s)
+}
+
+func rankSuggestions(suggestions []*Suggestion) []*Suggestion {
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Score > suggestions[j].Score
+	})
+	return suggestions
+}
+
+func attrValue(n *htmlquery.Node, key string) string {
+	v, _ := n.AttributeValue(key)
+	return v
+}
+
+func hasToken(s, token string) bool {
+	for _, t := range strings.Fields(s) {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
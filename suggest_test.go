@@ -0,0 +1,72 @@
+package extractor
+
+import (
+	"fmt"
+	"testing"
+)
+
+const suggestHtml = `<html><body>
+	<div id="main">
+		<h1 class="title">Hello World</h1>
+		<ul class="items">
+			<li class="item"><a href="/p/1">Apple</a><span class="price">$10</span></li>
+			<li class="item"><a href="/p/2">Banana</a><span class="price">$25</span></li>
+			<li class="item"><a href="/p/3">Cherry</a><span class="price">$3</span></li>
+		</ul>
+	</div>
+</body></html>`
+
+func TestSuggestXPath(t *testing.T) {
+	e := ExtractHtmlString(suggestHtml)
+
+	ss := e.SuggestXPath("Hello World")
+	if len(ss) == 0 || ss[0].XPath != "//h1" || fmt.Sprint(ss[0].Values) != "[Hello World]" {
+		t.Fatal(ss)
+	}
+	if ss[0].Tag() != `exp:"//h1"` {
+		t.Error(ss[0].Tag())
+	}
+
+	// 相似的值
+	ss = e.SuggestXPath("hello  world!")
+	if len(ss) == 0 || fmt.Sprint(ss[0].Values) != "[Hello World]" || ss[0].Score >= 1 {
+		t.Error(ss)
+	}
+
+	// 属性
+	ss = e.SuggestXPath("/p/2")
+	if len(ss) == 0 || fmt.Sprint(ss[0].Values) != "[/p/2]" {
+		t.Fatal(ss)
+	}
+	if xp, err := e.XPath(ss[0].XPath); err != nil || fmt.Sprint(xp.GetTexts()) != "[/p/2]" {
+		t.Error(ss[0].XPath, err)
+	}
+
+	if ss = e.SuggestXPath("not exists"); len(ss) != 0 {
+		t.Error(ss)
+	}
+}
+
+func TestSuggestListXPath(t *testing.T) {
+	e := ExtractHtmlString(suggestHtml)
+
+	ss := e.SuggestListXPath("Apple", "Cherry")
+	if len(ss) == 0 {
+		t.Fatal("no suggestion")
+	}
+	for _, s := range ss {
+		if fmt.Sprint(s.Values) != "[Apple Banana Cherry]" {
+			t.Error(s.XPath, s.Values)
+		}
+	}
+
+	ss = e.SuggestListXPath("$10", "$25")
+	if len(ss) == 0 || fmt.Sprint(ss[0].Values) != "[$10 $25 $3]" {
+		t.Error(ss)
+	}
+
+	ss = e.SuggestListXPath("/p/1", "/p/3")
+	if len(ss) == 0 || fmt.Sprint(ss[0].Values) != "[/p/1 /p/2 /p/3]" {
+		t.Error(ss)
+	}
+}
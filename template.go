@@ -0,0 +1,155 @@
+package extractor
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/474420502/extractor/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// BoilerplateAttr the attribute marked on the boilerplate subtrees by Template.Mark. eg: //*[@data-boilerplate]
+const BoilerplateAttr = "data-boilerplate"
+
+// TemplateRatio the min ratio of the pages which share the subtree as template
+var TemplateRatio = 0.8
+
+// Template the boilerplate subtrees(header footer nav sidebar...) shared by the pages of the same site
+type Template struct {
+	keys map[uint64]struct{} // the keys of the shared subtrees
+}
+
+// LearnTemplate learns the subtrees which have the same content at the same path in most of the pages(TemplateRatio).
+// at least 2 pages
+func LearnTemplate(etors ...*HmtlExtractor) *Template {
+	tpl := &Template{keys: make(map[uint64]struct{})}
+	if len(etors) < 2 {
+		return tpl
+	}
+
+	counts := make(map[uint64]int)
+	for _, etor := range etors {
+		// 同一个页面的相同子树只计数一次
+		seen := make(map[uint64]bool)
+		for _, key := range subtreeKeys(etor.doc) {
+			if !seen[key] {
+				seen[key] = true
+				counts[key]++
+			}
+		}
+	}
+
+	min := int(math.Ceil(TemplateRatio * float64(len(etors))))
+	if min < 2 {
+		min = 2
+	}
+	for key, count := range counts {
+		if count >= min {
+			tpl.keys[key] = struct{}{}
+		}
+	}
+	return tpl
+}
+
+// Len the count of the learned subtrees
+func (tpl *Template) Len() int {
+	return len(tpl.keys)
+}
+
+// Boilerplate the outermost boilerplate subtrees of the page
+func (tpl *Template) Boilerplate(etor *HmtlExtractor) *XPath {
+	keys := subtreeKeys(etor.doc)
+
+	var results []*htmlquery.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if tpl.has(keys, c) {
+				results = append(results, (*htmlquery.Node)(c))
+			} else {
+				walk(c)
+			}
+		}
+	}
+	walk((*html.Node)(etor.doc))
+
+	xp := newXPath(results...)
+	xp.funcs = etor.funcs
+	return xp
+}
+
+func (tpl *Template) has(keys map[*html.Node]uint64, n *html.Node) bool {
+	key, ok := keys[n]
+	if ok {
+		_, ok = tpl.keys[key]
+	}
+	return ok
+}
+
+// Mark sets BoilerplateAttr="true" on the boilerplate subtrees of the page. eg: //p[not(ancestor-or-self::*[@data-boilerplate])]
+func (tpl *Template) Mark(etor *HmtlExtractor) {
+	for _, n := range tpl.Boilerplate(etor).GetXPathResults() {
+		n.SetAttr(BoilerplateAttr, "true")
+	}
+}
+
+// Strip removes the boilerplate subtrees from the page
+func (tpl *Template) Strip(etor *HmtlExtractor) {
+	for _, n := range tpl.Boilerplate(etor).GetXPathResults() {
+		n.Remove()
+	}
+}
+
+// subtreeKeys the key of element subtree with text: hash of the tag path from the root and the content.
+// html head body are not included
+func subtreeKeys(doc *htmlquery.Node) map[*html.Node]uint64 {
+	keys := make(map[*html.Node]uint64)
+
+	// 返回内容的hash和是否有文本
+	var walk func(n *html.Node, path string) (uint64, bool)
+	walk = func(n *html.Node, path string) (uint64, bool) {
+		h := fnv.New64a()
+		switch n.Type {
+		case html.TextNode:
+			text := htmlquery.NormalizeSpace(n.Data)
+			h.Write([]byte("#text:" + text))
+			return h.Sum64(), text != ""
+		case html.ElementNode:
+			path += "/" + n.Data
+			h.Write([]byte(n.Data))
+			attrs := make([]string, 0, len(n.Attr))
+			for _, attr := range n.Attr {
+				if attr.Key != BoilerplateAttr {
+					attrs = append(attrs, attr.Key+"="+attr.Val)
+				}
+			}
+			sort.Strings(attrs)
+			h.Write([]byte("[" + strings.Join(attrs, "\x00") + "]"))
+		case html.DocumentNode:
+		default:
+			return 0, false
+		}
+
+		var hasText bool
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			ch, text := walk(c, path)
+			if ch != 0 {
+				h.Write([]byte(strconv.FormatUint(ch, 16) + ","))
+			}
+			hasText = hasText || text
+		}
+		sum := h.Sum64()
+
+		if n.Type == html.ElementNode && hasText && n.Data != "html" && n.Data != "head" && n.Data != "body" {
+			kh := fnv.New64a()
+			kh.Write([]byte(path + "|" + strconv.FormatUint(sum, 16)))
+			keys[n] = kh.Sum64()
+		}
+		return sum, hasText
+	}
+	walk((*html.Node)(doc), "")
+	return keys
+}
@@ -0,0 +1,80 @@
+package extractor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	first := ExtractHtmlString(`<html><head><title>First</title></head><body>
+		<div class="header"><a href="/">Home</a> <a href="/news">News</a></div>
+		<div class="main">
+			<h1>First</h1>
+			<p>the first page</p>
+			<p class="share">Share this page</p>
+		</div>
+		<div class="footer">Copyright 2023 <a href="/about">About</a></div>
+	</body></html>`)
+	second := ExtractHtmlString(`<html><head><title>Second</title></head><body>
+		<div class="header"><a href="/">Home</a> <a href="/news">News</a></div>
+		<div class="main">
+			<h1>Second</h1>
+			<p>the second page</p>
+			<p class="share">Share this page</p>
+		</div>
+		<div class="footer">Copyright 2023 <a href="/about">About</a></div>
+	</body></html>`)
+	third := ExtractHtmlString(`<html><head><title>Third</title></head><body>
+		<div class="header"><a href="/">Home</a> <a href="/news">News</a></div>
+		<div class="main">
+			<h1>Third</h1>
+			<p>the third page</p>
+			<p class="share">Share this page</p>
+		</div>
+		<div class="footer">Copyright 2023 <a href="/about">About</a></div>
+	</body></html>`)
+	tpl := LearnTemplate(first, second, third)
+	if tpl.Len() == 0 {
+		t.Fatal("nothing learned")
+	}
+
+	page := ExtractHtmlString(`<html><head><title>New</title></head><body>
+		<div class="header"><a href="/">Home</a> <a href="/news">News</a></div>
+		<div class="main">
+			<h1>New</h1>
+			<p>the new page</p>
+			<p class="share">Share this page</p>
+		</div>
+		<div class="footer">Copyright 2023 <a href="/about">About</a></div>
+	</body></html>`)
+	bp := tpl.Boilerplate(page)
+	classes, _ := bp.ForEachAttrValue(".", "class")
+	if fmt.Sprint(classes) != "[header share footer]" {
+		t.Error(classes)
+	}
+
+	tpl.Mark(page)
+	xp, _ := page.XPath("//a[not(ancestor-or-self::*[@data-boilerplate])]")
+	if xp.Len() != 0 {
+		t.Error(xp.GetTexts())
+	}
+	xp, _ = page.XPath("//*[@data-boilerplate]")
+	if xp.Len() != 3 {
+		t.Error(xp.Len())
+	}
+	if tpl.Boilerplate(page).Len() != 3 {
+		t.Error("marked page should be same")
+	}
+
+	tpl.Strip(page)
+	body, _ := page.XPath("//body")
+	if text := strings.Join(strings.Fields(body.GetTexts()[0]), " "); text != "New the new page" {
+		t.Error(text)
+	}
+
+	// 一个页面不能学习
+	if LearnTemplate(first).Len() != 0 {
+		t.Error("one page")
+	}
+}
@@ -14,6 +14,10 @@ var register = make(map[string]reflect.Value)
 func init() {
 	Register("ParseNumber", ParseNumber) // 自定义函数
 	Register("ExtractNumber", ExtractNumber)
+	Register("SimilarText", SimilarText)
+	Register("Levenshtein", Levenshtein)
+	Register("JaroWinkler", JaroWinkler)
+	Register("TokenSetRatio", TokenSetRatio)
 }
 
 // Register you can register custom function to tag
@@ -64,51 +68,3 @@ func ParseNumber(snum string) (float64, error) {
 
 	return i * factor, nil
 }
-
-func SimilarText(one, two string) (percent float64) {
-	similarText(one, two, &percent)
-	return percent
-}
-
-func similarText(first, second string, percent *float64) int {
-	var similarText func(string, string, int, int) int
-	similarText = func(str1, str2 string, len1, len2 int) int {
-		var sum, max int
-		pos1, pos2 := 0, 0
-
-		for i := 0; i < len1; i++ {
-			for j := 0; j < len2; j++ {
-				for l := 0; (i+l < len1) && (j+l < len2) && (str1[i+l] == str2[j+l]); l++ {
-					if l+1 > max {
-						max = l + 1
-						pos1 = i
-						pos2 = j
-					}
-				}
-			}
-		}
-
-		if sum = max; sum > 0 {
-			if pos1 > 0 && pos2 > 0 {
-				sum += similarText(str1, str2, pos1, pos2)
-			}
-			if (pos1+max < len1) && (pos2+max < len2) {
-				s1 := []byte(str1)
-				s2 := []byte(str2)
-				sum += similarText(string(s1[pos1+max:]), string(s2[pos2+max:]), len1-pos1-max, len2-pos2-max)
-			}
-		}
-
-		return sum
-	}
-
-	l1, l2 := len(first), len(second)
-	if l1+l2 == 0 {
-		return 0
-	}
-	sim := similarText(first, second, l1, l2)
-	if percent != nil {
-		*percent = float64(sim*200) / float64(l1+l2)
-	}
-	return sim
-}
@@ -0,0 +1,120 @@
+package extractor
+
+import (
+	"strings"
+
+	"github.com/474420502/extractor/htmlquery"
+)
+
+// Len the count of XPath results
+func (xp *XPath) Len() int {
+	return len(xp.results)
+}
+
+// Filter new XPath with the results that fn returns true
+func (xp *XPath) Filter(fn func(*htmlquery.Node) bool) *XPath {
+	var results []*htmlquery.Node
+	for _, n := range xp.results {
+		if fn(n) {
+			results = append(results, n)
+		}
+	}
+	return xp.derive(results)
+}
+
+// Map new XPath with the nodes returned by fn. nil is skipped
+func (xp *XPath) Map(fn func(*htmlquery.Node) *htmlquery.Node) *XPath {
+	var results []*htmlquery.Node
+	for _, n := range xp.results {
+		if mn := fn(n); mn != nil {
+			results = append(results, mn)
+		}
+	}
+	return xp.derive(results)
+}
+
+// Each call fn with the index and every result. return xp for chaining
+func (xp *XPath) Each(fn func(i int, n *htmlquery.Node)) *XPath {
+	for i, n := range xp.results {
+		fn(i, n)
+	}
+	return xp
+}
+
+// First new XPath with the first result
+func (xp *XPath) First() *XPath {
+	return xp.Eq(0)
+}
+
+// Last new XPath with the last result
+func (xp *XPath) Last() *XPath {
+	return xp.Eq(-1)
+}
+
+// Eq new XPath with the result at index i. negative i counts from the end. out of range is empty
+func (xp *XPath) Eq(i int) *XPath {
+	if i < 0 {
+		i += len(xp.results)
+	}
+	if i < 0 || i >= len(xp.results) {
+		return xp.derive(nil)
+	}
+	return xp.derive(xp.results[i : i+1])
+}
+
+// Slice new XPath with the results[i:j]. negative index counts from the end, out of range is clamped
+func (xp *XPath) Slice(i, j int) *XPath {
+	l := len(xp.results)
+	clamp := func(v int) int {
+		if v < 0 {
+			v += l
+		}
+		if v < 0 {
+			return 0
+		}
+		if v > l {
+			return l
+		}
+		return v
+	}
+	i, j = clamp(i), clamp(j)
+	if i >= j {
+		return xp.derive(nil)
+	}
+	return xp.derive(xp.results[i:j])
+}
+
+// HasText new XPath with the results that Text() contains text
+func (xp *XPath) HasText(text string) *XPath {
+	return xp.Filter(func(n *htmlquery.Node) bool {
+		return strings.Contains(n.Text(), text)
+	})
+}
+
+// HasAttr new XPath with the results that have the attribute key. if value is given, the attribute value must be one of value
+func (xp *XPath) HasAttr(key string, value ...string) *XPath {
+	return xp.Filter(func(n *htmlquery.Node) bool {
+		attr := n.GetAttributeByKey(key)
+		if attr == nil {
+			return false
+		}
+		if len(value) == 0 {
+			return true
+		}
+		for _, v := range value {
+			if attr.Val == v {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// MapTo map every result of xp to T. eg: MapTo(xp, (*htmlquery.Node).Text)
+func MapTo[T any](xp *XPath, fn func(*htmlquery.Node) T) []T {
+	var ret []T
+	for _, n := range xp.results {
+		ret = append(ret, fn(n))
+	}
+	return ret
+}
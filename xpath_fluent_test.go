@@ -0,0 +1,73 @@
+package extractor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/474420502/extractor/htmlquery"
+)
+
+func TestXPathFluent(t *testing.T) {
+	e := ExtractHtmlString(`<html><body><ul>
+		<li data-price="10">apple</li>
+		<li data-price="25" class="hot">banana</li>
+		<li>cherry</li>
+		<li data-price="3" class="hot">durian</li>
+	</ul></body></html>`)
+
+	xp, _ := e.XPath("//li")
+	if xp.Len() != 4 {
+		t.Error(xp.Len())
+	}
+
+	cheap := xp.HasAttr("data-price").Filter(func(n *htmlquery.Node) bool {
+		v, _ := n.AttributeValue("data-price")
+		f, _ := strconv.ParseFloat(v, 64)
+		return f < 20
+	})
+	if fmt.Sprint(cheap.GetTexts()) != "[apple durian]" {
+		t.Error(cheap.GetTexts())
+	}
+
+	if texts := xp.HasAttr("class", "hot").HasText("an").GetTexts(); fmt.Sprint(texts) != "[banana durian]" {
+		t.Error(texts)
+	}
+
+	if xp.First().GetTexts()[0] != "apple" || xp.Last().GetTexts()[0] != "durian" || xp.Eq(-2).GetTexts()[0] != "cherry" {
+		t.Error("First Last Eq error")
+	}
+
+	if xp.Eq(10).Len() != 0 || xp.Slice(3, 1).Len() != 0 {
+		t.Error("out of range should be empty")
+	}
+
+	if texts := xp.Slice(1, -1).GetTexts(); fmt.Sprint(texts) != "[banana cherry]" {
+		t.Error(texts)
+	}
+
+	if texts := xp.Slice(-2, 100).GetTexts(); fmt.Sprint(texts) != "[cherry durian]" {
+		t.Error(texts)
+	}
+
+	parents := xp.Map(func(n *htmlquery.Node) *htmlquery.Node { return n.GetParent() })
+	if names := parents.GetTagNames(); fmt.Sprint(names) != "[ul]" {
+		t.Error(names)
+	}
+
+	var idx []int
+	xp.Each(func(i int, n *htmlquery.Node) { idx = append(idx, i) }).First()
+	if fmt.Sprint(idx) != "[0 1 2 3]" {
+		t.Error(idx)
+	}
+
+	upper := MapTo(xp.Slice(0, 2), func(n *htmlquery.Node) string { return strings.ToUpper(n.Text()) })
+	if fmt.Sprint(upper) != "[APPLE BANANA]" {
+		t.Error(upper)
+	}
+
+	if lens := MapTo(xp, func(n *htmlquery.Node) int { return len(n.Text()) }); fmt.Sprint(lens) != "[5 6 6 6]" {
+		t.Error(lens)
+	}
+}
@@ -0,0 +1,128 @@
+package extractor
+
+import "github.com/474420502/extractor/htmlquery"
+
+// Group the results of executing xpath on a source node(Parent)
+type Group struct {
+	Parent *htmlquery.Node   // the source node in XPath results
+	Nodes  []*htmlquery.Node // the xpath results of Parent, in document order
+	Values []interface{}     // the values of do(node) without nil
+}
+
+// ForEachGroupEx like ForEachEx, but returns one Group per XPath result.
+// the groups are aligned with GetXPathResults(), empty groups are preserved.
+func (xp *XPath) ForEachGroupEx(exp string, do func(*htmlquery.Node) interface{}) (groups []Group, errorlist []error) {
+	for _, xpresult := range xp.results {
+		result, err := xp.funcs.QueryAll(xpresult, exp)
+		if err != nil {
+			if xp.errorFlags == ErrorSkip {
+				errorlist = append(errorlist, err)
+			} else {
+				break
+			}
+		}
+
+		group := Group{Parent: xpresult, Nodes: result}
+		for _, n := range result {
+			if want := do(n); want != nil {
+				group.Values = append(group.Values, want)
+			}
+		}
+		groups = append(groups, group)
+	}
+	return
+}
+
+// ForEachGroup like ForEach, but returns one XPath per XPath result
+func (xp *XPath) ForEachGroup(exp string) (xps []*XPath, errorlist []error) {
+	groups, errorlist := xp.ForEachGroupEx(exp, func(node *htmlquery.Node) interface{} {
+		return nil
+	})
+
+	for _, g := range groups {
+		xps = append(xps, xp.derive(g.Nodes))
+	}
+	return xps, errorlist
+}
+
+// ForEachGroupText like ForEachText, but returns the texts of every XPath result
+func (xp *XPath) ForEachGroupText(exp string) (texts [][]string, errorlist []error) {
+	return xp.forEachGroupStrings(exp, func(node *htmlquery.Node) []string {
+		return []string{node.Text()}
+	})
+}
+
+// ForEachGroupString like ForEachString, but returns the strings of every XPath result
+func (xp *XPath) ForEachGroupString(exp string) (sstr [][]string, errorlist []error) {
+	return xp.forEachGroupStrings(exp, func(node *htmlquery.Node) []string {
+		return []string{node.OutputHTML(true)}
+	})
+}
+
+// ForEachGroupTagName like ForEachTagName, but returns the tag names of every XPath result
+func (xp *XPath) ForEachGroupTagName(exp string) (names [][]string, errorlist []error) {
+	return xp.forEachGroupStrings(exp, func(node *htmlquery.Node) []string {
+		if txt, err := node.TagName(); err == nil {
+			return []string{txt}
+		}
+		return nil
+	})
+}
+
+// ForEachGroupAttrKeys like ForEachAttrKeys, but returns the attribute keys of every XPath result
+func (xp *XPath) ForEachGroupAttrKeys(exp string) (keyslist [][]string, errorlist []error) {
+	return xp.forEachGroupStrings(exp, func(node *htmlquery.Node) []string {
+		var ir []string
+		for _, attr := range node.Attributes() {
+			ir = append(ir, attr.GetKey())
+		}
+		return ir
+	})
+}
+
+// ForEachGroupAttrValue like ForEachAttrValue, but returns the attribute values of every XPath result
+func (xp *XPath) ForEachGroupAttrValue(exp string, attributes ...string) (values [][]string, errorlist []error) {
+	return xp.forEachGroupStrings(exp, func(node *htmlquery.Node) []string {
+		var ir []string
+		for _, attr := range attributes {
+			if attribute := node.GetAttributeByKey(attr); attribute != nil {
+				ir = append(ir, attribute.GetValue())
+			}
+		}
+		return ir
+	})
+}
+
+// ForEachGroupAttr like ForEachAttr, but returns the attributes of every XPath result
+func (xp *XPath) ForEachGroupAttr(exp string) (attributes [][]*htmlquery.Attribute, errorlist []error) {
+	groups, errorlist := xp.ForEachGroupEx(exp, func(node *htmlquery.Node) interface{} {
+		return node.Attributes()
+	})
+
+	for _, g := range groups {
+		var attrs []*htmlquery.Attribute
+		for _, i := range g.Values {
+			attrs = append(attrs, i.([]*htmlquery.Attribute)...)
+		}
+		attributes = append(attributes, attrs)
+	}
+	return attributes, errorlist
+}
+
+func (xp *XPath) forEachGroupStrings(exp string, do func(*htmlquery.Node) []string) (values [][]string, errorlist []error) {
+	groups, errorlist := xp.ForEachGroupEx(exp, func(node *htmlquery.Node) interface{} {
+		if ir := do(node); len(ir) > 0 {
+			return ir
+		}
+		return nil
+	})
+
+	for _, g := range groups {
+		var strs []string
+		for _, i := range g.Values {
+			strs = append(strs, i.([]string)...)
+		}
+		values = append(values, strs)
+	}
+	return values, errorlist
+}
@@ -0,0 +1,56 @@
+package extractor
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestForEachGroup(t *testing.T) {
+	e := ExtractHtmlString(`<html><body>
+		<div class="card"><b>A</b><i data-v="1">x</i><i data-v="2">y</i></div>
+		<div class="card"><b>B</b></div>
+		<div class="card"><b>C</b><i data-v="3">z</i></div>
+	</body></html>`)
+
+	xp, _ := e.XPath("//div[@class='card']")
+
+	texts, errs := xp.ForEachGroupText(".//i")
+	if len(errs) > 0 || fmt.Sprintf("%q", texts) != `[["x" "y"] [] ["z"]]` {
+		t.Errorf("%q %v", texts, errs)
+	}
+
+	values, _ := xp.ForEachGroupAttrValue(".//i", "data-v")
+	if fmt.Sprintf("%q", values) != `[["1" "2"] [] ["3"]]` {
+		t.Errorf("%q", values)
+	}
+
+	names, _ := xp.ForEachGroupTagName("./*")
+	if fmt.Sprint(names) != "[[b i i] [b] [b i]]" {
+		t.Error(names)
+	}
+
+	attrs, _ := xp.ForEachGroupAttr(".//i")
+	if len(attrs) != 3 || len(attrs[0]) != 2 || len(attrs[1]) != 0 {
+		t.Error(attrs)
+	}
+
+	keys, _ := xp.ForEachGroupAttrKeys(".//i")
+	if fmt.Sprint(keys) != "[[data-v data-v] [] [data-v]]" {
+		t.Error(keys)
+	}
+
+	strs, _ := xp.ForEachGroupString("./b")
+	if fmt.Sprint(strs) != "[[<b>A</b>] [<b>B</b>] [<b>C</b>]]" {
+		t.Error(strs)
+	}
+
+	xps, _ := xp.ForEachGroup(".//i")
+	if len(xps) != 3 || len(xps[1].GetXPathResults()) != 0 || fmt.Sprint(xps[2].GetTexts()) != "[z]" {
+		t.Error(xps)
+	}
+
+	groups, errs := xp.ForEachGroupText(".//i[")
+	if len(errs) != 3 || len(groups) != 3 {
+		t.Error(groups, errs)
+	}
+}
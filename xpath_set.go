@@ -0,0 +1,88 @@
+package extractor
+
+import "github.com/474420502/extractor/htmlquery"
+
+// Union the nodes in xp or other. the results are in document order
+func (xp *XPath) Union(other *XPath) *XPath {
+	var results []*htmlquery.Node
+	results = append(results, xp.results...)
+	if other != nil {
+		results = append(results, other.results...)
+	}
+	return xp.derive(results)
+}
+
+// Intersect the nodes in both xp and other
+func (xp *XPath) Intersect(other *XPath) *XPath {
+	set := other.nodeSet()
+	var results []*htmlquery.Node
+	for _, n := range xp.results {
+		if _, ok := set[n.Identity()]; ok {
+			results = append(results, n)
+		}
+	}
+	return xp.derive(results)
+}
+
+// Except the nodes in xp but not in other
+func (xp *XPath) Except(other *XPath) *XPath {
+	set := other.nodeSet()
+	var results []*htmlquery.Node
+	for _, n := range xp.results {
+		if _, ok := set[n.Identity()]; !ok {
+			results = append(results, n)
+		}
+	}
+	return xp.derive(results)
+}
+
+// Contains all nodes of other are in xp
+func (xp *XPath) Contains(other *XPath) bool {
+	if other == nil {
+		return true
+	}
+	set := xp.nodeSet()
+	for _, n := range other.results {
+		if _, ok := set[n.Identity()]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsNode the node is in xp
+func (xp *XPath) ContainsNode(node *htmlquery.Node) bool {
+	for _, n := range xp.results {
+		if n.Identity() == node.Identity() {
+			return true
+		}
+	}
+	return false
+}
+
+func (xp *XPath) nodeSet() map[interface{}]struct{} {
+	set := make(map[interface{}]struct{})
+	if xp != nil {
+		for _, n := range xp.results {
+			set[n.Identity()] = struct{}{}
+		}
+	}
+	return set
+}
+
+// derive new XPath with the same settings of xp
+func (xp *XPath) derive(results []*htmlquery.Node) *XPath {
+	nxp := newXPath(results...)
+	nxp.errorFlags = xp.errorFlags
+	nxp.funcs = xp.funcs
+	return nxp
+}
+
+// Diff the structural changes from the results of xp(old) to the results of other(new). eg: the same xpath of yesterday's and today's page
+func (xp *XPath) Diff(other *XPath) []*htmlquery.Change {
+	var news []*htmlquery.Node
+	if other != nil {
+		news = other.results
+	}
+	return htmlquery.DiffNodes(xp.results, news)
+}
@@ -0,0 +1,62 @@
+package extractor
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestXPathSet(t *testing.T) {
+	e := ExtractHtmlString(`<html><body>
+		<div class="a"><span>1</span><span>2</span></div>
+		<div class="a b"><span>3</span></div>
+		<div class="b"><span>4</span><span>5</span></div>
+	</body></html>`)
+
+	xa, _ := e.XPath("//div[has-class('a')]")
+	xb, _ := e.XPath("//div[has-class('b')]")
+
+	if u := xb.Union(xa); u.Len() != 3 || fmt.Sprint(u.GetTexts()) != "[12 3 45]" {
+		t.Error(u.GetTexts())
+	}
+
+	if i := xa.Intersect(xb); fmt.Sprint(i.GetTexts()) != "[3]" {
+		t.Error(i.GetTexts())
+	}
+
+	if x := xa.Except(xb); fmt.Sprint(x.GetTexts()) != "[12]" {
+		t.Error(x.GetTexts())
+	}
+
+	if !xa.Union(xb).Contains(xa) || xa.Contains(xb) || !xa.ContainsNode(xa.GetXPathResults()[0]) {
+		t.Error("Contains is error")
+	}
+
+	// ForEach 系列的结果按文档顺序 不重复
+	xp, _ := e.XPath("//div | //body")
+	for i := 0; i < 10; i++ {
+		texts, errs := xp.ForEachText(".//span")
+		if len(errs) > 0 || fmt.Sprint(texts) != "[1 2 3 4 5]" {
+			t.Fatal(texts, errs)
+		}
+	}
+	spans, _ := xp.ForEach(".//span")
+	if fmt.Sprint(spans.GetTexts()) != "[1 2 3 4 5]" {
+		t.Error(spans.GetTexts())
+	}
+}
+
+func TestXPathDiff(t *testing.T) {
+	old, _ := ExtractHtmlString(`<ul><li>a</li><li>b <i>1</i></li></ul><p>x</p>`).XPath("//li")
+	new, _ := ExtractHtmlString(`<ul><li>a</li><li>b <i>2</i></li><li>c</li></ul><p>y</p>`).XPath("//li")
+
+	var got []string
+	for _, c := range old.Diff(new) {
+		got = append(got, fmt.Sprint(c.Type, " ", c.OldValue, "->", c.NewValue, " ", c.NewXPath))
+	}
+	if fmt.Sprint(got) != "[text-changed 1->2 /html[1]/body[1]/ul[1]/li[2]/i[1]/text()[1] inserted -> /html[1]/body[1]/ul[1]/li[3]]" {
+		t.Error(got)
+	}
+	if len(old.Diff(old)) != 0 {
+		t.Error("same results")
+	}
+}
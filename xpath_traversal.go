@@ -0,0 +1,180 @@
+package extractor
+
+import (
+	"github.com/474420502/extractor/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// Parent new XPath with the parent elements of the results
+func (xp *XPath) Parent() *XPath {
+	var results []*htmlquery.Node
+	for _, n := range xp.results {
+		if p := n.GetParent(); isElement(p) {
+			results = append(results, p)
+		}
+	}
+	return xp.derive(results)
+}
+
+// Parents new XPath with the ancestor elements of the results, until the ancestor matches the expression `until`(exclusive).
+// `until` is evaluated from the document root. eg: //div[@class='list']. empty `until` means all ancestors
+func (xp *XPath) Parents(until string) (*XPath, error) {
+	match, err := xp.matcher(until)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*htmlquery.Node
+	for _, n := range xp.results {
+		for p := n.GetParent(); isElement(p) && !match(p); p = p.GetParent() {
+			results = append(results, p)
+		}
+	}
+	return xp.derive(results), nil
+}
+
+// Closest new XPath with the first element that matches the expression, testing the result itself and then its ancestors.
+// the expression is evaluated from the document root. eg: //div[has-class('card')]
+func (xp *XPath) Closest(exp string) (*XPath, error) {
+	if exp == "" {
+		return xp.derive(nil), nil
+	}
+	match, err := xp.matcher(exp)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*htmlquery.Node
+	for _, n := range xp.results {
+		for p := n; isElement(p); p = p.GetParent() {
+			if match(p) {
+				results = append(results, p)
+				break
+			}
+		}
+	}
+	return xp.derive(results), nil
+}
+
+// Children new XPath with the child elements of the results
+func (xp *XPath) Children() *XPath {
+	var results []*htmlquery.Node
+	for _, n := range xp.results {
+		for c := n.First(); c != nil; c = c.Next() {
+			if isElement(c) {
+				results = append(results, c)
+			}
+		}
+	}
+	return xp.derive(results)
+}
+
+// Siblings new XPath with the sibling elements of the results, not include the results themselves
+func (xp *XPath) Siblings() *XPath {
+	var results []*htmlquery.Node
+	for _, n := range xp.results {
+		if p := n.GetParent(); p != nil && !n.IsAttribute() {
+			for c := p.First(); c != nil; c = c.Next() {
+				if c != n && isElement(c) {
+					results = append(results, c)
+				}
+			}
+		}
+	}
+	return xp.derive(results)
+}
+
+// NextAll new XPath with all the following sibling elements of the results
+func (xp *XPath) NextAll() *XPath {
+	nxp, _ := xp.NextUntil("")
+	return nxp
+}
+
+// PrevAll new XPath with all the preceding sibling elements of the results
+func (xp *XPath) PrevAll() *XPath {
+	nxp, _ := xp.PrevUntil("")
+	return nxp
+}
+
+// NextUntil new XPath with the following sibling elements of the results, until the sibling matches the expression `until`(exclusive).
+func (xp *XPath) NextUntil(until string) (*XPath, error) {
+	return xp.siblingsUntil(until, (*htmlquery.Node).Next)
+}
+
+// PrevUntil new XPath with the preceding sibling elements of the results, until the sibling matches the expression `until`(exclusive).
+func (xp *XPath) PrevUntil(until string) (*XPath, error) {
+	return xp.siblingsUntil(until, (*htmlquery.Node).Prev)
+}
+
+func (xp *XPath) siblingsUntil(until string, move func(*htmlquery.Node) *htmlquery.Node) (*XPath, error) {
+	match, err := xp.matcher(until)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*htmlquery.Node
+	for _, n := range xp.results {
+		for s := move(n); s != nil; s = move(s) {
+			if !isElement(s) {
+				continue
+			}
+			if match(s) {
+				break
+			}
+			results = append(results, s)
+		}
+	}
+	return xp.derive(results), nil
+}
+
+// matcher the node matches exp if it is in the results of exp evaluated from its document root.
+// empty exp matches nothing
+func (xp *XPath) matcher(exp string) (func(*htmlquery.Node) bool, error) {
+	if exp == "" {
+		return func(*htmlquery.Node) bool { return false }, nil
+	}
+
+	sets := make(map[*htmlquery.Node]map[interface{}]struct{})
+	query := func(root *htmlquery.Node) (map[interface{}]struct{}, error) {
+		if set, ok := sets[root]; ok {
+			return set, nil
+		}
+		nodes, err := xp.funcs.QueryAll(root, exp)
+		if err != nil {
+			return nil, err
+		}
+		set := make(map[interface{}]struct{}, len(nodes))
+		for _, n := range nodes {
+			set[n.Identity()] = struct{}{}
+		}
+		sets[root] = set
+		return set, nil
+	}
+
+	// 先检查表达式是否正确
+	for _, n := range xp.results {
+		if _, err := query(documentRoot(n)); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(n *htmlquery.Node) bool {
+		set, err := query(documentRoot(n))
+		if err != nil {
+			return false
+		}
+		_, ok := set[n.Identity()]
+		return ok
+	}, nil
+}
+
+func documentRoot(n *htmlquery.Node) *htmlquery.Node {
+	for n.Parent != nil {
+		n = n.GetParent()
+	}
+	return n
+}
+
+func isElement(n *htmlquery.Node) bool {
+	return n != nil && n.Type == html.ElementNode
+}
@@ -0,0 +1,77 @@
+package extractor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/474420502/extractor/htmlquery"
+)
+
+func TestXPathTraversal(t *testing.T) {
+	e := ExtractHtmlString(`<html><body><div class="list">
+		<div class="card" id="c1"><h2>one</h2><p id="p1">a</p><span>s1</span><p id="p2">b</p><em>e</em></div>
+		<div class="card" id="c2"><h2>two</h2><p id="p3">c</p></div>
+	</div></body></html>`)
+
+	ids := func(xp *XPath) string {
+		return fmt.Sprint(MapTo(xp, func(n *htmlquery.Node) string {
+			v, _ := n.AttributeValue("id")
+			if v == "" {
+				v, _ = n.TagName()
+			}
+			return v
+		}))
+	}
+
+	ps, _ := e.XPath("//p")
+	if s := ids(ps.Parent()); s != "[c1 c2]" {
+		t.Error(s)
+	}
+
+	if parents, err := ps.Parents(""); err != nil || ids(parents) != "[html body div c1 c2]" {
+		t.Error(ids(parents), err)
+	}
+
+	if parents, err := ps.Parents("//div[@class='list']"); err != nil || ids(parents) != "[c1 c2]" {
+		t.Error(ids(parents), err)
+	}
+
+	if closest, err := ps.Closest("//div[has-class('card')]"); err != nil || ids(closest) != "[c1 c2]" {
+		t.Error(ids(closest), err)
+	}
+
+	cards, _ := e.XPath("//div[@class='card']")
+	if closest, err := cards.Closest("//div"); err != nil || ids(closest) != "[c1 c2]" {
+		t.Error(ids(closest), err)
+	}
+
+	if _, err := ps.Closest("//div["); err == nil {
+		t.Error("err should not be nil")
+	}
+
+	if s := ids(cards.Children()); s != "[h2 p1 span p2 em h2 p3]" {
+		t.Error(s)
+	}
+
+	p1, _ := e.XPath("//p[@id='p1']")
+	if s := ids(p1.Siblings()); s != "[h2 span p2 em]" {
+		t.Error(s)
+	}
+
+	if s := ids(p1.NextAll()); s != "[span p2 em]" {
+		t.Error(s)
+	}
+
+	if s := ids(p1.PrevAll()); s != "[h2]" {
+		t.Error(s)
+	}
+
+	if next, err := p1.NextUntil("//em"); err != nil || ids(next) != "[span p2]" {
+		t.Error(ids(next), err)
+	}
+
+	// 结果按文档顺序 不重复
+	if s := ids(ps.Siblings()); s != "[h2 p1 span p2 em h2]" {
+		t.Error(s)
+	}
+}